@@ -0,0 +1,401 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//ImportDir imports the package in dir, which, unlike with Import,
+//need not be in $GOPATH, as when comparing a package with a copy of
+//another version of it checked out to a temporary directory.
+//
+//If dir is in $GOPATH, ImportDir is the same as Import.
+//Otherwise the Package is cached by its absolute directory.
+func ImportDir(ctx *build.Context, dir string) (*Package, error) {
+	if ctx == nil {
+		ctx = defaultctx
+	}
+	if _, _, err := ToImport(dir); err == nil {
+		return Import(ctx, dir)
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ident := ident{ctx, dir}
+	if pkg := pkgget(ident); pkg != nil {
+		return pkg, nil
+	}
+
+	p, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		Context: ctx,
+		Build:   p,
+	}
+	pkgset(ident, pkg)
+
+	return pkg, nil
+}
+
+//apiQualifier writes types from other packages with their package name,
+//and types from this package unqualified.
+func apiQualifier(pkg *types.Package) types.Qualifier {
+	return func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+}
+
+//apiType formats t for an API feature, writing untyped constant types
+//as ideal-int, ideal-string, and so on.
+func apiType(t types.Type, q types.Qualifier) string {
+	if b, ok := t.(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		switch b.Kind() {
+		case types.UntypedRune:
+			return "ideal-char"
+		case types.UntypedNil:
+			return "nil"
+		}
+		return "ideal-" + strings.TrimPrefix(b.Name(), "untyped ")
+	}
+	return types.TypeString(t, q)
+}
+
+//apiSignature formats a signature without parameter names,
+//such as ([]uint8) (int, error).
+func apiSignature(sig *types.Signature, q types.Qualifier) string {
+	var b bytes.Buffer
+	tuple := func(t *types.Tuple, variadic bool) {
+		b.WriteByte('(')
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			typ := t.At(i).Type()
+			if variadic && i == t.Len()-1 {
+				b.WriteString("...")
+				typ = typ.(*types.Slice).Elem()
+			}
+			b.WriteString(types.TypeString(typ, q))
+		}
+		b.WriteByte(')')
+	}
+
+	if tps := sig.TypeParams(); tps != nil && tps.Len() > 0 {
+		b.WriteString(typeParams(tps, q))
+	}
+	tuple(sig.Params(), sig.Variadic())
+	switch res := sig.Results(); res.Len() {
+	case 0:
+	case 1:
+		b.WriteString(" " + types.TypeString(res.At(0).Type(), q))
+	default:
+		b.WriteByte(' ')
+		tuple(res, false)
+	}
+	return b.String()
+}
+
+func typeParams(tps *types.TypeParamList, q types.Qualifier) string {
+	var acc []string
+	for i := 0; i < tps.Len(); i++ {
+		tp := tps.At(i)
+		acc = append(acc, tp.Obj().Name()+" "+types.TypeString(tp.Constraint(), q))
+	}
+	return "[" + strings.Join(acc, ", ") + "]"
+}
+
+//apiFeatures returns the features of a single exported object.
+func apiFeatures(obj types.Object, q types.Qualifier) (fs []string) {
+	name := obj.Name()
+	switch o := obj.(type) {
+	case *types.Const:
+		fs = append(fs,
+			fmt.Sprintf("const %s %s", name, apiType(o.Type(), q)),
+			fmt.Sprintf("const %s = %s", name, o.Val().ExactString()))
+
+	case *types.Var:
+		fs = append(fs, fmt.Sprintf("var %s %s", name, types.TypeString(o.Type(), q)))
+
+	case *types.Func:
+		sig := o.Type().(*types.Signature)
+		if recv := sig.Recv(); recv != nil {
+			fs = append(fs, fmt.Sprintf("method (%s) %s%s", types.TypeString(recv.Type(), q), name, apiSignature(sig, q)))
+		} else {
+			fs = append(fs, fmt.Sprintf("func %s%s", name, apiSignature(sig, q)))
+		}
+
+	case *types.TypeName:
+		if o.IsAlias() {
+			return append(fs, fmt.Sprintf("type %s = %s", name, types.TypeString(o.Type(), q)))
+		}
+		if n, ok := o.Type().(*types.Named); ok && n.TypeParams() != nil && n.TypeParams().Len() > 0 {
+			name += typeParams(n.TypeParams(), q)
+		}
+
+		switch u := o.Type().Underlying().(type) {
+		case *types.Struct:
+			fs = append(fs, fmt.Sprintf("type %s struct", name))
+			for i := 0; i < u.NumFields(); i++ {
+				f := u.Field(i)
+				switch {
+				case !f.Exported():
+				case f.Embedded():
+					fs = append(fs, fmt.Sprintf("type %s struct, embedded %s", name, types.TypeString(f.Type(), q)))
+				default:
+					fs = append(fs, fmt.Sprintf("type %s struct, %s %s", name, f.Name(), types.TypeString(f.Type(), q)))
+				}
+			}
+
+		case *types.Interface:
+			fs = append(fs, fmt.Sprintf("type %s interface", name))
+			unexported := false
+			for i := 0; i < u.NumMethods(); i++ {
+				m := u.Method(i)
+				if !m.Exported() {
+					unexported = true
+					continue
+				}
+				fs = append(fs, fmt.Sprintf("type %s interface, %s%s", name, m.Name(), apiSignature(m.Type().(*types.Signature), q)))
+			}
+			if unexported {
+				fs = append(fs, fmt.Sprintf("type %s interface, unexported methods", name))
+			}
+
+		default:
+			fs = append(fs, fmt.Sprintf("type %s %s", name, types.TypeString(u, q)))
+		}
+	}
+	return
+}
+
+//apiName returns the name features of obj are grouped under:
+//its own name, or, for a method, its receiver's base type and name.
+func apiName(obj types.Object) string {
+	if f, ok := obj.(*types.Func); ok {
+		if recv := f.Type().(*types.Signature).Recv(); recv != nil {
+			t := recv.Type()
+			if p, ok := t.(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			if n, ok := t.(*types.Named); ok {
+				return n.Obj().Name() + "." + f.Name()
+			}
+		}
+	}
+	return obj.Name()
+}
+
+//apiByName returns the features of the exported API of p grouped by name.
+func (p *Package) apiByName() (map[string][]string, error) {
+	if err := p.TypeCheck(); p.Types == nil {
+		return nil, err
+	}
+	q := apiQualifier(p.Types)
+	api := map[string][]string{}
+	for _, d := range p.Decls().SplitSpecs() {
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv != nil {
+			//methods of unexported types are not part of the API,
+			//unless the type is embedded, which is not worth the trouble.
+			recv := fd.Recv.List[0].Type
+			for {
+				switch t := recv.(type) {
+				case *ast.StarExpr:
+					recv = t.X
+					continue
+				case *ast.IndexExpr:
+					recv = t.X
+					continue
+				case *ast.IndexListExpr:
+					recv = t.X
+					continue
+				}
+				break
+			}
+			if id, ok := recv.(*ast.Ident); !ok || !id.IsExported() {
+				continue
+			}
+		}
+		for _, obj := range p.Objects(d) {
+			if obj.Exported() {
+				name := apiName(obj)
+				api[name] = append(api[name], apiFeatures(obj, q)...)
+			}
+		}
+	}
+	return api, nil
+}
+
+//API returns the exported API of p, sorted, one feature per line,
+//in the format of the Go project's api files, without the leading
+//package name. For example,
+//	func Import(*build.Context, string) (*Package, error)
+//	method (*Package) Parse(bool) error
+//	type Block struct
+//	type Block struct, Kind op
+//	type StringMatcher interface, MatchString(string) bool
+//
+//API calls TypeCheck. If type checking fails entirely, the error
+//is returned. Type errors that go/types recovers from are ignored.
+func (p *Package) API() ([]string, error) {
+	api, err := p.apiByName()
+	if err != nil {
+		return nil, err
+	}
+	var fs []string
+	for _, f := range api {
+		fs = append(fs, f...)
+	}
+	sort.Strings(fs)
+	return fs, nil
+}
+
+//A Change classifies an APIChange.
+type Change int
+
+const (
+	//Added marks a name that is only in the new API.
+	Added Change = iota
+	//Removed marks a name that is only in the old API.
+	Removed
+	//Changed marks a name whose features differ.
+	Changed
+)
+
+//String returns the name of c in lower case.
+func (c Change) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("Change(%d)", int(c))
+}
+
+//An APIChange is a difference in the features under a single exported
+//name between two versions of a package. A method is named by its
+//receiver's base type and its name, as in Package.Parse.
+type APIChange struct {
+	Name   string
+	Change Change
+	//Old and New are the features present only in the old
+	//or only in the new API, respectively.
+	Old, New []string
+	//Breaking is set if code using the old API could fail to compile
+	//with the new API.
+	Breaking bool
+}
+
+//DiffAPI compares the exported APIs of two versions of a package,
+//as returned by API, and returns the changes ordered by name.
+//
+//Removing or changing any feature is a breaking change, as is adding
+//a method to an existing interface, except that a method may change from
+//a pointer to a value receiver. Adding a name, or adding a field to
+//a struct or a method to a type, is a compatible change.
+//
+//DiffAPI calls TypeCheck on both packages.
+func DiffAPI(old, new *Package) ([]APIChange, error) {
+	oapi, err := old.apiByName()
+	if err != nil {
+		return nil, err
+	}
+	napi, err := new.apiByName()
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for nm := range oapi {
+		names[nm] = true
+	}
+	for nm := range napi {
+		names[nm] = true
+	}
+	var sorted []string
+	for nm := range names {
+		sorted = append(sorted, nm)
+	}
+	sort.Strings(sorted)
+
+	var out []APIChange
+	for _, nm := range sorted {
+		of, nf := oapi[nm], napi[nm]
+		c := APIChange{
+			Name: nm,
+			Old:  setMinus(of, nf),
+			New:  setMinus(nf, of),
+		}
+		switch {
+		case len(of) == 0:
+			c.Change = Added
+		case len(nf) == 0:
+			c.Change = Removed
+			c.Breaking = true
+		case len(c.Old) > 0 || len(c.New) > 0:
+			c.Change = Changed
+			c.Breaking = len(c.Old) > 0
+			//a method moving from a pointer to a value receiver
+			//remains in the method set of the pointer.
+			if len(c.Old) == 1 && len(c.New) == 1 && strings.HasPrefix(c.Old[0], "method (*") {
+				c.Breaking = strings.Replace(c.Old[0], "(*", "(", 1) != c.New[0]
+			}
+			if iface := interfaceFeature(nf, nm); iface != "" {
+				for _, f := range c.New {
+					if strings.HasPrefix(f, iface+", ") {
+						c.Breaking = true
+					}
+				}
+			}
+		default:
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+//interfaceFeature returns the feature among fs declaring name an interface,
+//including any type parameters, such as "type List[T any] interface",
+//or "" if there is none.
+func interfaceFeature(fs []string, name string) string {
+	prefix := "type " + name
+	for _, f := range fs {
+		if !strings.HasPrefix(f, prefix) || !strings.HasSuffix(f, " interface") {
+			continue
+		}
+		if rest := f[len(prefix):]; rest[0] == ' ' || rest[0] == '[' {
+			return f
+		}
+	}
+	return ""
+}
+
+//setMinus returns the strings of a not in b, sorted.
+func setMinus(a, b []string) (out []string) {
+	in := Exact(b...)
+	for _, s := range a {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return
+}
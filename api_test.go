@@ -0,0 +1,109 @@
+package goutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const apiOld = `package a
+
+const X = 1
+
+type S struct {
+	A int
+	b int
+}
+
+type I interface {
+	M()
+}
+
+type G[T any] interface {
+	M(T)
+}
+
+func Gone() {}
+
+func (s *S) Ptr() {}
+
+func (s S) Val() {}
+`
+
+const apiNew = `package a
+
+const X = 2
+
+type S struct {
+	A int
+	B string
+}
+
+type I interface {
+	M()
+	N()
+}
+
+type G[T any] interface {
+	M(T)
+	N()
+}
+
+func New() *S { return nil }
+
+func (s S) Ptr() {}
+
+func (s *S) Val() {}
+`
+
+func TestAPI(t *testing.T) {
+	api, err := importSrc(t, apiOld).API()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"const X = 1",
+		"const X ideal-int",
+		"func Gone()",
+		"method (*S) Ptr()",
+		"method (S) Val()",
+		"type G[T any] interface",
+		"type G[T any] interface, M(T)",
+		"type I interface",
+		"type I interface, M()",
+		"type S struct",
+		"type S struct, A int",
+	}
+	if !reflect.DeepEqual(api, expected) {
+		t.Errorf("got %q\nexpected %q", api, expected)
+	}
+}
+
+func TestDiffAPI(t *testing.T) {
+	cs, err := DiffAPI(importSrc(t, apiOld), importSrc(t, apiNew))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name     string
+		change   Change
+		breaking bool
+	}{
+		{"G", Changed, true},
+		{"Gone", Removed, true},
+		{"I", Changed, true},
+		{"New", Added, false},
+		{"S", Changed, false},
+		{"S.Ptr", Changed, false},
+		{"S.Val", Changed, true},
+		{"X", Changed, true},
+	}
+	if len(cs) != len(expected) {
+		t.Fatalf("got %d changes, expected %d: %+v", len(cs), len(expected), cs)
+	}
+	for i, c := range cs {
+		e := expected[i]
+		if c.Name != e.name || c.Change != e.change || c.Breaking != e.breaking {
+			t.Errorf("%d: got %s %s %v, expected %s %s %v", i, c.Name, c.Change, c.Breaking, e.name, e.change, e.breaking)
+		}
+	}
+}
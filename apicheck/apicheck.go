@@ -0,0 +1,192 @@
+//Apicheck records the exported API of a Go package, or set of Go packages,
+//with the standard build tags, and checks later versions against that record.
+//
+//The API is recorded in a text file with one feature of the API per line,
+//in the format of the api files of the Go project, such as
+//	pkg github.com/jimmyfrasche/goutil, func Import(*build.Context, string) (*Package, error)
+//sorted, so that the file may be kept under version control and
+//changes to it reviewed.
+//
+//With -w, apicheck writes the API of the packages to the file.
+//Otherwise, it compares the API of the packages against the file
+//and prints each feature added, prefixed by +, or removed, prefixed by -,
+//grouped by package.
+//
+//The packages may be specified as with the go(1) tool, including the
+//special ... operator. Commands and internal packages are skipped.
+//
+//Apicheck exits with status 0 if the API is unchanged, 1 if it has changed,
+//and 2 if there is an error. With -compat, additions are reported but only
+//removals, which break the packages' users, cause an exit status of 1.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	file   = flag.String("f", "api.txt", "the API `file`")
+	write  = flag.Bool("w", false, "write the API file instead of checking it")
+	compat = flag.Bool("compat", false, "only fail if features are removed")
+	tags   = gocli.TagsFlag("")
+)
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+func fatal(v ...interface{}) {
+	log.Println(v...)
+	os.Exit(2)
+}
+
+//api returns the sorted features of every package.
+func api(pkgs goutil.Packages) (lines []string) {
+	for _, p := range pkgs {
+		path := p.Build.ImportPath
+		if p.Build.Name == "main" || strings.HasPrefix(path, "internal/") || strings.Contains(path, "/internal/") || strings.HasSuffix(path, "/internal") {
+			continue
+		}
+		fs, err := p.API()
+		if err != nil {
+			fatal(err)
+		}
+		for _, f := range fs {
+			lines = append(lines, fmt.Sprintf("pkg %s, %s", path, f))
+		}
+	}
+	sort.Strings(lines)
+	return
+}
+
+func read(name string) (lines []string) {
+	f, err := os.Open(name)
+	if err != nil {
+		fatal(err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err = sc.Err(); err != nil {
+		fatal(err)
+	}
+	return
+}
+
+//pkgOf splits a line into its package and feature.
+func pkgOf(line string) (pkg, feature string) {
+	line = strings.TrimPrefix(line, "pkg ")
+	if i := strings.Index(line, ", "); i >= 0 {
+		return line[:i], line[i+2:]
+	}
+	return "", line
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+	pkgs := gocli.Flatten(pss)
+	if err = pkgs.Parse(false); err != nil {
+		fatal(err)
+	}
+	cur := api(pkgs)
+
+	if *write {
+		out := strings.Join(cur, "\n")
+		if out != "" {
+			out += "\n"
+		}
+		if err = ioutil.WriteFile(*file, []byte(out), 0666); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	os.Exit(check(os.Stdout, read(*file), cur, *compat))
+}
+
+//check writes the difference between the features old and cur, grouped
+//by package, to w, and returns the exit status it calls for.
+func check(w io.Writer, old, cur []string, compat bool) int {
+	inOld, inCur := map[string]bool{}, map[string]bool{}
+	for _, l := range old {
+		inOld[l] = true
+	}
+	for _, l := range cur {
+		inCur[l] = true
+	}
+
+	//package → +/- lines
+	diffs := map[string][]string{}
+	var pkgnames []string
+	add := func(prefix, line string) {
+		pkg, feature := pkgOf(line)
+		if _, ok := diffs[pkg]; !ok {
+			pkgnames = append(pkgnames, pkg)
+		}
+		diffs[pkg] = append(diffs[pkg], prefix+feature)
+	}
+	removed, added := false, false
+	for _, l := range old {
+		if !inCur[l] {
+			add("-", l)
+			removed = true
+		}
+	}
+	for _, l := range cur {
+		if !inOld[l] {
+			add("+", l)
+			added = true
+		}
+	}
+
+	sort.Strings(pkgnames)
+	for _, pkg := range pkgnames {
+		ds := diffs[pkg]
+		//order by feature, removals before additions of the same feature
+		sort.SliceStable(ds, func(i, j int) bool {
+			return ds[i][1:] < ds[j][1:]
+		})
+		fmt.Fprintf(w, "%s:\n", pkg)
+		for _, d := range ds {
+			fmt.Fprintf(w, "\t%s\n", d)
+		}
+	}
+	return status(added, removed, compat)
+}
+
+//status returns the exit status for an API to which features were added
+//or removed: 1 if it changed, or, if compat, only if features were removed,
+//and 0 otherwise.
+func status(added, removed, compat bool) int {
+	if removed || (added && !compat) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	table := []struct {
+		added, removed, compat bool
+		status                 int
+	}{
+		{false, false, false, 0},
+		{false, false, true, 0},
+		{true, false, false, 1},
+		{true, false, true, 0},
+		{false, true, false, 1},
+		{false, true, true, 1},
+		{true, true, false, 1},
+		{true, true, true, 1},
+	}
+	for _, c := range table {
+		if got := status(c.added, c.removed, c.compat); got != c.status {
+			t.Errorf("added %v, removed %v, compat %v: got %d expected %d", c.added, c.removed, c.compat, got, c.status)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	old := []string{
+		"pkg a, func F()",
+		"pkg a, func G(int)",
+		"pkg b, const C = 1",
+	}
+	table := []struct {
+		cur    []string
+		compat bool
+		out    string
+		status int
+	}{
+		{old, false, "", 0},
+		{
+			append(old[:3:3], "pkg b, const D = 2"), true,
+			"b:\n\t+const D = 2\n", 0,
+		},
+		{
+			append(old[:3:3], "pkg b, const D = 2"), false,
+			"b:\n\t+const D = 2\n", 1,
+		},
+		{
+			[]string{"pkg a, func F()", "pkg a, func G(string)", "pkg b, const C = 1"}, true,
+			"a:\n\t-func G(int)\n\t+func G(string)\n", 1,
+		},
+		{
+			[]string{"pkg a, func G(int)"}, false,
+			"a:\n\t-func F()\nb:\n\t-const C = 1\n", 1,
+		},
+	}
+	for i, c := range table {
+		var b bytes.Buffer
+		if got := check(&b, old, c.cur, c.compat); got != c.status {
+			t.Errorf("%d: got status %d expected %d", i, got, c.status)
+		}
+		if b.String() != c.out {
+			t.Errorf("%d: got\n%s\nexpected\n%s", i, b.String(), c.out)
+		}
+	}
+}
@@ -64,48 +64,25 @@ func (ds Decls) Vars() Decls {
 	return ds.gendecl(token.VAR)
 }
 
-func dupdecl(g *ast.GenDecl, s ast.Spec) *ast.GenDecl {
-	return &ast.GenDecl{
-		Doc:    g.Doc,
-		TokPos: g.TokPos,
-		Tok:    g.Tok,
-		Lparen: g.Lparen,
-		Specs:  []ast.Spec{s},
-		Rparen: g.Rparen,
-	}
-}
-
-//SplitSpecs goes throught each *ast.GenDecl and duplicates the outer GenDecl
-//for each item in the spec. This destroys information but does make it easier
-//to search, and is required for invoking Named on GenDecls.
+//SplitSpecs goes through each *ast.GenDecl and creates a new GenDecl
+//for each item in the spec, as described by Specs and Spec.GenDecl.
+//This destroys information, though much of it is retained by Specs,
+//but does make it easier to search, and is required for invoking
+//Named on GenDecls.
 //
-//Note that SplitSpecs does not, and in general cannot sensibly, split
-//	var a, b, c = f()
-//though it does split
+//Each split GenDecl has the doc comment of its own spec and the position
+//of its own spec. SplitSpecs splits both
 //	var a, b, c = 1, 2, 3
-//as one would hope.
+//and
+//	var a, b, c int
+//as one would hope, but, as it cannot sensibly do otherwise, it keeps
+//	var a, b, c = f()
+//together as a single GenDecl.
 func (ds Decls) SplitSpecs() (out Decls) {
 	for _, d := range ds {
 		if g, ok := d.(*ast.GenDecl); ok {
-			for _, s := range g.Specs {
-				switch st := s.(type) {
-				case *ast.ValueSpec:
-					if len(st.Names) > 1 && len(st.Names) == len(st.Values) {
-						for i := range st.Names {
-							out = append(out, dupdecl(g, &ast.ValueSpec{
-								Doc:     st.Doc,
-								Names:   []*ast.Ident{st.Names[i]},
-								Type:    st.Type,
-								Values:  []ast.Expr{st.Values[i]},
-								Comment: st.Comment,
-							}))
-						}
-					} else {
-						out = append(out, dupdecl(g, s))
-					}
-				case *ast.TypeSpec:
-					out = append(out, dupdecl(g, s))
-				}
+			for _, s := range specsOf(g) {
+				out = append(out, s.GenDecl())
 			}
 		} else {
 			out = append(out, d)
@@ -0,0 +1,147 @@
+package goutil
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+//A Constant is a package level constant evaluated by go/types.
+type Constant struct {
+	Name  string
+	Type  types.Type
+	Value constant.Value
+	//Object is the constant's types.Object.
+	Object *types.Const
+	//Spec is the entry that declared the constant.
+	Spec *Spec
+}
+
+//Constants returns every package level constant in p, in source order,
+//with its value and type computed by go/types, including constants
+//implicitly repeated in a const block and any use of iota.
+//Constants named _ are skipped.
+//
+//Constants calls TypeCheck. If TypeCheck returns an error, the constants
+//that could be evaluated are returned along with the error.
+func (p *Package) Constants() (cs []*Constant, err error) {
+	err = p.TypeCheck()
+	if p.Types == nil {
+		return nil, err
+	}
+	for _, s := range p.Decls().Consts().Specs() {
+		for _, nm := range s.Names {
+			c, ok := p.Info.Defs[nm].(*types.Const)
+			if !ok || c.Name() == "_" {
+				continue
+			}
+			cs = append(cs, &Constant{
+				Name:   c.Name(),
+				Type:   c.Type(),
+				Value:  c.Val(),
+				Object: c,
+				Spec:   s,
+			})
+		}
+	}
+	return
+}
+
+//An Enum is the set of constants of a single named type
+//declared in a package.
+type Enum struct {
+	Type *types.Named
+	//Constants of Type, in source order.
+	Constants []*Constant
+}
+
+//Enums groups the constants returned by Constants by their type.
+//Only constants of a named type, or an alias of one, are included.
+//The Enums are ordered
+//by the first appearance of one of their constants.
+//
+//Enums calls TypeCheck, as with Constants.
+func (p *Package) Enums() (es []*Enum, err error) {
+	cs, err := p.Constants()
+	idx := map[*types.Named]*Enum{}
+	for _, c := range cs {
+		//a constant declared with an alias is of the type it stands for.
+		n, ok := types.Unalias(c.Type).(*types.Named)
+		if !ok {
+			continue
+		}
+		e, ok := idx[n]
+		if !ok {
+			e = &Enum{Type: n}
+			idx[n] = e
+			es = append(es, e)
+		}
+		e.Constants = append(e.Constants, c)
+	}
+	return
+}
+
+type byValue []*Constant
+
+func (b byValue) Len() int      { return len(b) }
+func (b byValue) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byValue) Less(i, j int) bool {
+	if !ordered(b[i].Value) || !ordered(b[j].Value) {
+		return false
+	}
+	return constant.Compare(b[i].Value, token.LSS, b[j].Value)
+}
+
+//ordered reports whether v may be compared with token.LSS.
+func ordered(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float, constant.String:
+		return true
+	}
+	return false
+}
+
+//Sorted returns the constants of the enum sorted by value.
+//When more than one constant has the same value, only the first
+//declared is included, as with the names chosen by a String method.
+//
+//Booleans and complex numbers have no order, so the constants of
+//an enum of such a type are left in source order.
+func (e *Enum) Sorted() (out []*Constant) {
+	cs := append([]*Constant(nil), e.Constants...)
+	sort.Stable(byValue(cs))
+	for i, c := range cs {
+		if i > 0 && constant.Compare(c.Value, token.EQL, cs[i-1].Value) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return
+}
+
+//Runs splits the result of Sorted into runs of consecutive integer values,
+//which a generated String method may index as an array.
+//
+//If the enum's type is not an integer type, there are no runs.
+func (e *Enum) Runs() (runs [][]*Constant) {
+	if b, ok := e.Type.Underlying().(*types.Basic); !ok || b.Info()&types.IsInteger == 0 {
+		return nil
+	}
+	one := constant.MakeInt64(1)
+	var run []*Constant
+	for _, c := range e.Sorted() {
+		if len(run) > 0 {
+			next := constant.BinaryOp(run[len(run)-1].Value, token.ADD, one)
+			if !constant.Compare(c.Value, token.EQL, next) {
+				runs = append(runs, run)
+				run = nil
+			}
+		}
+		run = append(run, c)
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return
+}
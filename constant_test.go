@@ -0,0 +1,120 @@
+package goutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const constSrc = `package a
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+	_
+	Black = Red
+	White Color = 10
+)
+
+type Hue = Color
+
+const Cyan Hue = 11
+
+type Name string
+
+const (
+	Zed  Name = "z"
+	Abel Name = "a"
+)
+
+type Flag bool
+
+const (
+	On  Flag = true
+	Off Flag = false
+)
+
+type Z complex128
+
+const (
+	I Z = 1i
+	O Z = 0
+)
+
+const Untyped = 1 << 3
+
+const Sum = Untyped + 1
+`
+
+//constNames returns the names of cs separated by spaces.
+func constNames(cs []*Constant) string {
+	var acc []string
+	for _, c := range cs {
+		acc = append(acc, c.Name)
+	}
+	return strings.Join(acc, " ")
+}
+
+func TestConstants(t *testing.T) {
+	cs, err := importSrc(t, constSrc).Constants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := constNames(cs), "Red Green Blue Black White Cyan Zed Abel On Off I O Untyped Sum"; got != want {
+		t.Errorf("got %q expected %q", got, want)
+	}
+	values := map[string]string{
+		"Blue":  "2",
+		"Black": "0",
+		"White": "10",
+		"Abel":  `"a"`,
+		"On":    "true",
+		"Sum":   "9",
+	}
+	for _, c := range cs {
+		if v, ok := values[c.Name]; ok && c.Value.String() != v {
+			t.Errorf("%s: got %s expected %s", c.Name, c.Value, v)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	es, err := importSrc(t, constSrc).Enums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := []struct {
+		typ, consts, sorted string
+		runs                []string
+	}{
+		{"Color", "Red Green Blue Black White Cyan", "Red Green Blue White Cyan", []string{"Red Green Blue", "White Cyan"}},
+		{"Name", "Zed Abel", "Abel Zed", nil},
+		{"Flag", "On Off", "On Off", nil},
+		{"Z", "I O", "I O", nil},
+	}
+	if len(es) != len(table) {
+		t.Fatalf("expected %d enums, got %d", len(table), len(es))
+	}
+	for i, c := range table {
+		e := es[i]
+		if nm := e.Type.Obj().Name(); nm != c.typ {
+			t.Errorf("%d: got type %s expected %s", i, nm, c.typ)
+			continue
+		}
+		if got := constNames(e.Constants); got != c.consts {
+			t.Errorf("%s: got constants %q expected %q", c.typ, got, c.consts)
+		}
+		if got := constNames(e.Sorted()); got != c.sorted {
+			t.Errorf("%s: got sorted %q expected %q", c.typ, got, c.sorted)
+		}
+		var runs []string
+		for _, r := range e.Runs() {
+			runs = append(runs, constNames(r))
+		}
+		if strings.Join(runs, "|") != strings.Join(c.runs, "|") {
+			t.Errorf("%s: got runs %q expected %q", c.typ, runs, c.runs)
+		}
+	}
+}
@@ -8,9 +8,37 @@
 //operator. The -r flag searches both the specified package and
 //its dependencies, even when invoked with the ... operator.
 //
-//The line number in the output is not guaranteed to be exact,
-//except in the case of functions. Exported and unexported
-//declarations are searched.
+//The -fuzzy flag treats the regexp as a fuzzy pattern instead, matching
+//names that contain its characters in order, ignoring case, such as an
+//abbreviation of a camel case name, so that NGS matches NewGostrap.
+//The matches in each package are printed best first.
+//
+//The -q flag treats the regexp as a declaration query instead, such as
+//	kind:func recv:*Package name:/^Parse/ exported:true doc:~cache
+//which is documented in goutil.ParseQuery.
+//
+//The -v flag selects the declarations not matched by the regexp,
+//fuzzy pattern, or query. The fuzzy matches are then not ranked.
+//
+//The -implements, -assignable, and -satisfies flags type check the
+//packages and further restrict the matches to, respectively, types
+//implementing an interface, declarations assignable to a type, and
+//interfaces satisfied by a type. Types are written as Go type expressions
+//with each package name replaced by its import path, such as io.Writer,
+//*sync.Mutex, or []github.com/jimmyfrasche/goutil.Block.
+//Use the regexp . to match every name.
+//
+//The -nogenerated flag skips the declarations in generated files, those
+//with a "Code generated ... DO NOT EDIT." header, and the -generated flag
+//skips every other declaration.
+//
+//Each match is printed with the file, line, and column of the name
+//declared. The file is given relative to the root of the package's
+//import path, or, with -abs, as an absolute path.
+//Exported and unexported declarations are searched.
+//
+//With -body, each match is followed by its complete source, and with -doc,
+//by its documentation. With both, the source includes the doc comment.
 package main
 
 import (
@@ -26,6 +54,7 @@ import (
 	"go/ast"
 	"go/printer"
 	"go/token"
+	"go/types"
 
 	"github.com/jimmyfrasche/goutil"
 )
@@ -35,15 +64,56 @@ var (
 	v        = flag.Bool("v", false, "select non-matching declarations")
 	l        = flag.Bool("l", false, "prefer leftmost-longest matches")
 	nostdlib = flag.Bool("nostdlib", false, "do not match against standard library")
+	fuzzy    = flag.Bool("fuzzy", false, "match names fuzzily instead of by regexp, best matches first")
+	q        = flag.Bool("q", false, "use a declaration query instead of a regexp")
+	abs      = flag.Bool("abs", false, "print absolute file names")
+	body     = flag.Bool("body", false, "print the complete source of each declaration")
+	docs     = flag.Bool("doc", false, "print the documentation of each declaration")
+	gen      = flag.Bool("generated", false, "only match declarations in generated files")
+	nogen    = flag.Bool("nogenerated", false, "do not match declarations in generated files")
+
+	implements = flag.String("implements", "", "select types implementing the interface `type`")
+	assignable = flag.String("assignable", "", "select declarations assignable to `type`")
+	satisfies  = flag.String("satisfies", "", "select interfaces satisfied by `type`")
 )
 
-//invert regex matches for -v
-type negmatcher struct {
-	re *regexp.Regexp
-}
+//a query further filters the matching declarations using type information.
+type query func(*types.Info, goutil.Decls) goutil.Decls
 
-func (n negmatcher) MatchString(s string) bool {
-	return !n.re.MatchString(s)
+//typeQueries returns the queries specified by the type flags, if any.
+func typeQueries() (qs []query, err error) {
+	if *implements != "" {
+		t, err := goutil.LookupType(nil, *implements)
+		if err != nil {
+			return nil, err
+		}
+		iface, ok := t.Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an interface", *implements)
+		}
+		qs = append(qs, func(info *types.Info, ds goutil.Decls) goutil.Decls {
+			return ds.Implements(info, iface)
+		})
+	}
+	if *assignable != "" {
+		t, err := goutil.LookupType(nil, *assignable)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, func(info *types.Info, ds goutil.Decls) goutil.Decls {
+			return ds.AssignableTo(info, t)
+		})
+	}
+	if *satisfies != "" {
+		t, err := goutil.LookupType(nil, *satisfies)
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, func(info *types.Info, ds goutil.Decls) goutil.Decls {
+			return ds.SatisfiedBy(info, t)
+		})
+	}
+	return
 }
 
 func Usage() {
@@ -64,10 +134,14 @@ func fmtast(fs *token.FileSet, v interface{}) string {
 	return s
 }
 
-func fmtpos(pkg *goutil.Package, pos token.Pos) string {
-	p := pkg.FileSet.Position(pos)
-	_, f := filepath.Split(p.Filename)
-	return fmt.Sprintf("%s:%d:", f, p.Line)
+func fmtpos(pkg *goutil.Package, d ast.Decl) string {
+	p := pkg.Position(d).Name
+	f := p.Filename
+	if !*abs {
+		_, f = filepath.Split(f)
+		f = pkg.Build.ImportPath + "/" + f
+	}
+	return fmt.Sprintf("%s:%d:%d:", f, p.Line, p.Column)
 }
 
 //print just types, caller handles ()
@@ -107,22 +181,52 @@ func fmtfunc(fs *token.FileSet, f *ast.FuncDecl) string {
 	return fmt.Sprintf("func%s %s%s%s", method, name, params, ret)
 }
 
-func print(showimp bool, p *goutil.Package, d ast.Decl) {
-	where, what := "", ""
-	if showimp {
-		where = p.Build.ImportPath + ":"
+//nocomment returns a copy of s without its doc or line comment,
+//as the printer prints those with the spec.
+func nocomment(s ast.Spec) ast.Spec {
+	switch st := s.(type) {
+	case *ast.ValueSpec:
+		c := *st
+		c.Doc, c.Comment = nil, nil
+		return &c
+	case *ast.TypeSpec:
+		c := *st
+		c.Doc, c.Comment = nil, nil
+		return &c
+	}
+	return s
+}
+
+func print(p *goutil.Package, d ast.Decl) {
+	if *body {
+		src, err := p.Source(d, *docs)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("%s\n%s\n\n", fmtpos(p, d), src)
+		return
 	}
 
+	what := ""
 	switch dt := d.(type) {
 	case *ast.FuncDecl:
-		where += fmtpos(p, dt.Type.Func)
 		what = fmtfunc(p.FileSet, dt)
 
 	case *ast.GenDecl:
-		where += fmtpos(p, dt.TokPos)
-		what = dt.Tok.String() + " " + fmtast(p.FileSet, dt.Specs[0])
+		what = dt.Tok.String() + " " + fmtast(p.FileSet, nocomment(dt.Specs[0]))
+	}
+	fmt.Println(fmtpos(p, d), what)
+
+	if *docs {
+		if doc := goutil.DeclDoc(d); doc != nil {
+			for _, line := range strings.SplitAfter(doc.Text(), "\n") {
+				if line != "" {
+					fmt.Print("\t", line)
+				}
+			}
+			fmt.Println()
+		}
 	}
-	fmt.Println(where, what)
 }
 
 //Usage: %name %flags regexp [package|directory]
@@ -138,17 +242,51 @@ func main() {
 		os.Exit(2)
 	}
 
-	re, err := regexp.Compile(args[0])
-	if err != nil {
-		fatal(err)
-	}
-	if *l {
-		re.Longest()
+	if *gen && *nogen {
+		fatal("-generated and -nogenerated cannot be used together")
 	}
 
-	var m goutil.StringMatcher = re
+	var m goutil.StringMatcher
+	var scorer goutil.Scorer
+	var match func(ast.Decl) bool
+	switch {
+	case *fuzzy && *q:
+		fatal("-fuzzy and -q cannot be used together")
+	case *fuzzy:
+		scorer = goutil.FuzzyMatcher(args[0])
+		m = scorer
+	case *q:
+		query, err := goutil.ParseQuery(args[0])
+		if err != nil {
+			fatal(err)
+		}
+		match = query.Match
+	default:
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			fatal(err)
+		}
+		if *l {
+			re.Longest()
+		}
+		m = re
+	}
 	if *v {
-		m = negmatcher{re}
+		switch {
+		case match != nil:
+			matched := match
+			match = func(d ast.Decl) bool {
+				return !matched(d)
+			}
+		default:
+			//there is nothing to rank
+			m, scorer = goutil.Not(m), nil
+		}
+	}
+
+	queries, err := typeQueries()
+	if err != nil {
+		fatal(err)
 	}
 
 	tree := false
@@ -199,15 +337,40 @@ func main() {
 		pkgs = pkgs.NoStdlib()
 	}
 
-	err = pkgs.Parse(false)
+	//doc comments are only needed by queries and to print documentation
+	err = pkgs.Parse(match != nil || *docs)
 	if err != nil {
 		fatal(err)
 	}
 
-	multiples := len(pkgs) > 1
+	if len(queries) > 0 {
+		//type errors are common in dependencies and rarely fatal to a query.
+		if err = pkgs.TypeCheck(); err != nil {
+			log.Println(err)
+		}
+	}
+
 	for _, pkg := range pkgs {
-		for _, d := range pkg.Decls().SplitSpecs().Named(m) {
-			print(multiples, pkg, d)
+		ds := pkg.Decls().SplitSpecs()
+		switch {
+		case *gen:
+			ds = ds.InGenerated(pkg)
+		case *nogen:
+			ds = ds.NotGenerated(pkg)
+		}
+		switch {
+		case scorer != nil:
+			ds = ds.Ranked(scorer)
+		case match != nil:
+			ds = ds.Filter(match)
+		default:
+			ds = ds.Named(m)
+		}
+		for _, q := range queries {
+			ds = q(pkg.Info, ds)
+		}
+		for _, d := range ds {
+			print(pkg, d)
 		}
 	}
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jimmyfrasche/goutil"
+)
+
+func TestFmtpos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "declgrep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "a.go")
+	if err = ioutil.WriteFile(file, []byte("package a\n\nvar (\n\tA = 1\n\tB = 2\n)\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	p, err := goutil.ImportDir(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(false); err != nil {
+		t.Fatal(err)
+	}
+	b := p.Decls().SplitSpecs()[1]
+
+	defer func(old bool) {
+		*abs = old
+	}(*abs)
+	for _, c := range []struct {
+		abs bool
+		out string
+	}{
+		{false, p.Build.ImportPath + "/a.go:5:2:"},
+		{true, file + ":5:2:"},
+	} {
+		*abs = c.abs
+		if got := fmtpos(p, b); got != c.out {
+			t.Errorf("abs %v: got %q expected %q", c.abs, got, c.out)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+)
+
+//diffContext is the number of unchanged lines around each hunk of a diff.
+const diffContext = 3
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lines := bytes.SplitAfter(b, []byte{'\n'})
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = string(l)
+	}
+	return out
+}
+
+//an op of a line diff: ' ' for a common line, '-' for a line only in
+//the old text, '+' for a line only in the new.
+type diffop struct {
+	kind byte
+	line string
+}
+
+//lineDiff returns the ops transforming a into b.
+//
+//It finds a longest common subsequence of the lines that remain after
+//trimming their common prefix and suffix, which is quadratic
+//but more than fast enough for the small edits it is used for.
+func lineDiff(a, b []string) (ops []diffop) {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	for _, l := range a[:pre] {
+		ops = append(ops, diffop{' ', l})
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	//lcs[i][j] is the length of the LCS of ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffop{' ', ma[i]})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffop{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffop{'+', mb[j]})
+			j++
+		}
+	}
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffop{' ', l})
+	}
+	return
+}
+
+//Diff returns a unified diff of the texts a and b, labeled with the
+//names an and bn. If the texts are the same, Diff returns nil.
+func Diff(an, bn string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	ops := lineDiff(splitLines(a), splitLines(b))
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", an, bn)
+
+	for start := 0; start < len(ops); {
+		//find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		//extend the hunk until there are more than 2*diffContext
+		//unchanged lines before the next change.
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				break
+			}
+			end = run
+		}
+
+		lo, hi := start-diffContext, end+diffContext
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+
+		//line numbers of the hunk in a and b
+		aline, bline := 1, 1
+		for _, op := range ops[:lo] {
+			if op.kind != '+' {
+				aline++
+			}
+			if op.kind != '-' {
+				bline++
+			}
+		}
+		alen, blen := 0, 0
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				alen++
+			}
+			if op.kind != '-' {
+				blen++
+			}
+		}
+		if alen == 0 {
+			aline--
+		}
+		if blen == 0 {
+			bline--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aline, alen, bline, blen)
+		for _, op := range ops[lo:hi] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if op.line == "" || op.line[len(op.line)-1] != '\n' {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = hi
+	}
+	return out.Bytes()
+}
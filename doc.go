@@ -6,8 +6,8 @@
 //from it. There are many miscellaneous utilities for easing
 //the use of the go/* packages.
 //
-//DocParse has been extracted from the go/doc package as this functionality
-//is not exported.
+//DocParse and ParseDoc have been adapted from the go/doc packages as this
+//functionality is not exported.
 //
 //Importing
 //
@@ -0,0 +1,311 @@
+package goutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+//A DocProblem is a problem with the documentation of a package,
+//as reported by LintDocs.
+type DocProblem struct {
+	Pos     token.Position
+	Message string
+}
+
+//String returns the problem as a file:line:column diagnostic.
+func (d DocProblem) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+type byProblemPos []DocProblem
+
+func (b byProblemPos) Len() int      { return len(b) }
+func (b byProblemPos) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byProblemPos) Less(i, j int) bool {
+	if b[i].Pos.Filename != b[j].Pos.Filename {
+		return b[i].Pos.Filename < b[j].Pos.Filename
+	}
+	return b[i].Pos.Offset < b[j].Pos.Offset
+}
+
+//A docLint is the state of a single LintDocs.
+type docLint struct {
+	p        *Package
+	fs       *token.FileSet
+	problems []DocProblem
+}
+
+func (l *docLint) report(pos token.Pos, format string, args ...interface{}) {
+	l.problems = append(l.problems, DocProblem{l.fs.Position(pos), fmt.Sprintf(format, args...)})
+}
+
+//LintDocs reports the problems with the documentation of p:
+//	a missing package doc comment
+//	a package doc comment not beginning with "Package name"
+//	an exported declaration without a doc comment
+//	an exported declaration whose doc comment does not begin with its name
+//	a line beginning with # that DocParse does not recognize as a heading
+//	a doc link, such as [io.Reader], to a declaration that does not exist
+//The problems are sorted by position.
+//
+//The package doc comment of a command need not begin with "Package",
+//and the declarations of a command, or in a generated file, need not
+//be documented. A doc comment of a type may begin with an article,
+//as in "A Block is".
+//The values in a parenthesized group with a doc comment
+//need not have their own.
+//
+//LintDocs calls ParseDocs and TypeCheck, and parses the package again
+//to read its comments. Doc links to other packages are checked by
+//importing them.
+func (p *Package) LintDocs() ([]DocProblem, error) {
+	if err := p.ParseDocs(0); err != nil {
+		return nil, err
+	}
+	if err := p.TypeCheck(); p.Types == nil {
+		return nil, err
+	}
+	pkg, fs, err := p.parse(true)
+	if err != nil {
+		return nil, err
+	}
+	l := &docLint{p: p, fs: fs}
+
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var files []*ast.File
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+
+	l.pkgDoc(files)
+	for i, f := range files {
+		if p.Generated(names[i]) {
+			continue
+		}
+		for _, d := range f.Decls {
+			l.decl(d)
+		}
+	}
+
+	sort.Sort(byProblemPos(l.problems))
+	return l.problems, nil
+}
+
+//pkgDoc checks the package doc comment.
+func (l *docLint) pkgDoc(files []*ast.File) {
+	if len(files) == 0 {
+		return
+	}
+	var doc *ast.CommentGroup
+	for _, f := range files {
+		if f.Doc != nil {
+			doc = f.Doc
+			break
+		}
+	}
+	name := l.p.Build.Name
+	if l.p.Doc.Doc == "" {
+		l.report(files[0].Package, "package %s has no doc comment", name)
+		return
+	}
+	//the doc may come from a file of package documentation.
+	if doc == nil {
+		return
+	}
+	if name != "main" && !strings.HasPrefix(doc.Text(), "Package "+name+" ") {
+		l.report(doc.Pos(), "package doc comment should begin with \"Package %s\"", name)
+	}
+	l.text(doc)
+}
+
+//exportedRecv reports whether the receiver of fd, if any, is exported.
+func exportedRecv(fd *ast.FuncDecl) bool {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return true
+	}
+	t := fd.Recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+			continue
+		case *ast.IndexExpr:
+			t = x.X
+			continue
+		case *ast.IndexListExpr:
+			t = x.X
+			continue
+		case *ast.Ident:
+			return ast.IsExported(x.Name)
+		}
+		return false
+	}
+}
+
+//decl checks the doc comments of d.
+func (l *docLint) decl(d ast.Decl) {
+	//the declarations of a command are not imported by anything.
+	required := l.p.Build.Name != "main"
+	switch dt := d.(type) {
+	case *ast.FuncDecl:
+		kind := "func"
+		if dt.Recv != nil {
+			kind = "method"
+		}
+		if dt.Name.IsExported() && exportedRecv(dt) {
+			l.named(dt.Doc, dt.Name, kind, required, false)
+		} else if dt.Doc != nil {
+			l.text(dt.Doc)
+		}
+
+	case *ast.GenDecl:
+		if dt.Tok == token.IMPORT {
+			return
+		}
+		grouped := dt.Lparen.IsValid()
+		if grouped && dt.Doc != nil {
+			l.text(dt.Doc)
+		}
+		for _, s := range dt.Specs {
+			doc, ids := dt.Doc, []*ast.Ident(nil)
+			switch st := s.(type) {
+			case *ast.TypeSpec:
+				ids = []*ast.Ident{st.Name}
+				if grouped {
+					doc = st.Doc
+				}
+			case *ast.ValueSpec:
+				ids = st.Names
+				if grouped {
+					doc = st.Doc
+				}
+			}
+			var exported *ast.Ident
+			for _, id := range ids {
+				if id.IsExported() {
+					exported = id
+					break
+				}
+			}
+			switch {
+			case exported == nil:
+				if doc != nil {
+					l.text(doc)
+				}
+			case doc == nil && grouped && dt.Doc != nil:
+				//the doc of the group covers its values.
+			case len(ids) > 1:
+				//the doc of a, b, c need not begin with a.
+				if doc != nil {
+					l.text(doc)
+				} else if required {
+					l.report(exported.Pos(), "exported %s %s has no doc comment", dt.Tok, exported.Name)
+				}
+			default:
+				l.named(doc, exported, dt.Tok.String(), required, dt.Tok == token.TYPE)
+			}
+		}
+	}
+}
+
+//named checks the doc comment of an exported declaration of id.
+func (l *docLint) named(doc *ast.CommentGroup, id *ast.Ident, kind string, required, article bool) {
+	if doc == nil {
+		if required {
+			l.report(id.Pos(), "exported %s %s has no doc comment", kind, id.Name)
+		}
+		return
+	}
+	text := doc.Text()
+	if article {
+		for _, a := range []string{"A ", "An ", "The "} {
+			if strings.HasPrefix(text, a) {
+				text = text[len(a):]
+				break
+			}
+		}
+	}
+	//the name must be a word of its own.
+	if rest := strings.TrimPrefix(text, id.Name); rest == text || identContinues(rest) {
+		l.report(doc.Pos(), "doc comment of %s should begin with %q", id.Name, id.Name)
+	}
+	l.text(doc)
+}
+
+//identContinues reports whether s begins with a character that may
+//continue an identifier.
+func identContinues(s string) bool {
+	id, _ := identPrefix("x" + s)
+	return len(id) > 1
+}
+
+//text checks the headings and doc links of doc.
+func (l *docLint) text(doc *ast.CommentGroup) {
+	l.blocks(doc, ParseDoc(doc.Text()).Blocks)
+}
+
+func (l *docLint) blocks(doc *ast.CommentGroup, bs []Block) {
+	for _, b := range bs {
+		for _, it := range b.Items {
+			l.blocks(doc, it.Blocks)
+		}
+		if b.Kind != Para {
+			continue
+		}
+		if strings.HasPrefix(b.Lines[0], "#") {
+			line := strings.TrimSpace(b.Lines[0])
+			l.report(l.line(doc, line), "%q is not a heading, which must be a single line of # and a space followed by text, between paragraphs", line)
+		}
+		for _, link := range b.Links {
+			if link.Doc != nil && !l.resolves(link.Doc) {
+				l.report(l.line(doc, "["+link.Text+"]"), "doc link [%s] refers to nothing", link.Text)
+			}
+		}
+	}
+}
+
+//line returns the position of the line of doc containing text,
+//or doc itself if there is none.
+func (l *docLint) line(doc *ast.CommentGroup, text string) token.Pos {
+	for _, c := range doc.List {
+		if i := strings.Index(c.Text, text); i >= 0 {
+			//the position of the start of the line of a /* */ comment.
+			if j := strings.LastIndexByte(c.Text[:i], '\n'); j >= 0 {
+				return c.Pos() + token.Pos(j+1)
+			}
+			return c.Pos()
+		}
+	}
+	return doc.Pos()
+}
+
+//resolves reports whether the target of a doc link exists.
+func (l *docLint) resolves(d *DocLink) bool {
+	p := l.p
+	if d.ImportPath != "" {
+		bp, err := p.Context.Import(d.ImportPath, p.Build.Dir, build.FindOnly)
+		if err != nil {
+			return false
+		}
+		if p, err = Import(p.Context, bp.Dir); err != nil {
+			return false
+		}
+		if d.Name == "" {
+			return true
+		}
+	}
+	name := d.Name
+	if d.Recv != "" {
+		name = d.Recv + "." + name
+	}
+	_, err := p.Lookup(name)
+	return err == nil
+}
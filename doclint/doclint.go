@@ -0,0 +1,65 @@
+//Doclint reports problems with the documentation of a Go package,
+//or set of Go packages, with the standard build tags.
+//
+//The packages may be specified as with the go(1) tool, including the
+//special ... operator.
+//
+//Doclint reports packages without a doc comment, exported declarations
+//without a doc comment or whose doc comment does not begin with the name
+//declared, lines beginning with # that are not headings, and doc links,
+//such as [io.Reader], to declarations that do not exist. The problems
+//checked are documented in goutil.Package.LintDocs.
+//
+//Each problem is printed with the file, line, and column of the
+//comment, or declaration, at fault.
+//
+//Doclint exits with status 1 if anything is reported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var tags = gocli.TagsFlag("")
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+
+	found := false
+	for _, p := range gocli.Flatten(pss) {
+		problems, err := p.LintDocs()
+		if err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		for _, d := range problems {
+			fmt.Println(d)
+			found = true
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
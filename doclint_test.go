@@ -0,0 +1,72 @@
+package goutil
+
+import (
+	"fmt"
+	"testing"
+)
+
+const lintSrc = `//Package a is linted.
+//
+//##Usage
+//
+//See [T.M], [io.Reader], [T.Missing], and [io.Missing].
+package a
+
+//A T is documented.
+type T struct{}
+
+//M is documented.
+func (T) M() {}
+
+func (T) Undocumented() {}
+
+//Docs are wrong.
+func F() {}
+
+//FooBar is not Foo.
+func Foo() {}
+
+//The values.
+const (
+	X = 1
+	Y = 2
+)
+
+const (
+	//Z is documented.
+	Z = 3
+	W = 4
+)
+
+var V, U = 1, 2
+
+//t is unexported and may refer to [Missing].
+type t struct{}
+`
+
+func TestLintDocs(t *testing.T) {
+	problems, err := importFile(t, lintSrc).LintDocs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`3:1: "##Usage" is not a heading, which must be a single line of # and a space followed by text, between paragraphs`,
+		`5:1: doc link [T.Missing] refers to nothing`,
+		`5:1: doc link [io.Missing] refers to nothing`,
+		`14:10: exported method Undocumented has no doc comment`,
+		`16:1: doc comment of F should begin with "F"`,
+		`19:1: doc comment of Foo should begin with "Foo"`,
+		`31:2: exported const W has no doc comment`,
+		`34:5: exported var V has no doc comment`,
+		`36:1: doc link [Missing] refers to nothing`,
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, d := range problems {
+		got := fmt.Sprintf("%d:%d: %s", d.Pos.Line, d.Pos.Column, d.Message)
+		if got != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], got)
+		}
+	}
+}
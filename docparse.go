@@ -1,26 +1,28 @@
 package goutil
 
 import (
+	"go/build"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 )
 
-//everything in this file was copied from the go/doc package as this is not exported
-//It can be found in go/doc/comment.go
+//Most of this file was adapted from the go/doc/comment package, which
+//parses the syntax of doc comments introduced in Go 1.19, as its parser
+//discards the original lines of each block.
 //
-//The only changes have been to export the functionality.
+//The changes have been to export the functionality and to retain the
+//Block format of the old go/doc parser this file used to be copied from.
 
-func indentLen(s string) int {
-	i := 0
-	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
-		i++
-	}
-	return i
+// indented reports whether line is indented
+// (starts with a leading space or tab).
+func indented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
 }
 
 func isBlank(s string) bool {
-	return len(s) == 0 || (len(s) == 1 && s[0] == '\n')
+	return strings.TrimSpace(s) == ""
 }
 
 func commonPrefix(a, b string) string {
@@ -31,66 +33,286 @@ func commonPrefix(a, b string) string {
 	return a[0:i]
 }
 
-func unindent(block []string) {
-	if len(block) == 0 {
-		return
+func leadingSpace(s string) string {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
 	}
+	return s[:i]
+}
 
-	// compute maximum common white prefix
-	prefix := block[0][0:indentLen(block[0])]
-	for _, line := range block {
+// unindent removes any common space/tab prefix
+// from each line in lines, returning a copy of lines in which
+// those prefixes have been trimmed from each line.
+// It also replaces any lines containing only spaces with blank lines (empty strings).
+func unindent(lines []string) []string {
+	// Trim leading and trailing blank lines.
+	for len(lines) > 0 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	// Compute and remove common indentation.
+	prefix := leadingSpace(lines[0])
+	for _, line := range lines[1:] {
 		if !isBlank(line) {
-			prefix = commonPrefix(prefix, line[0:indentLen(line)])
+			prefix = commonPrefix(prefix, leadingSpace(line))
 		}
 	}
-	n := len(prefix)
 
-	// remove
-	for i, line := range block {
-		if !isBlank(line) {
-			block[i] = line[n:]
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, prefix)
+		if isBlank(line) {
+			line = ""
 		}
+		out[i] = line
+	}
+	for len(out) > 0 && out[0] == "" {
+		out = out[1:]
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
 	}
+	return out
 }
 
-// heading returns the trimmed line if it passes as a section heading;
-// otherwise it returns the empty string.
-func heading(line string) string {
-	line = strings.TrimSpace(line)
-	if len(line) == 0 {
-		return ""
+// isOldHeading reports whether line is an old-style section heading.
+// line is all[off].
+func isOldHeading(line string, all []string, off int) bool {
+	if off <= 0 || all[off-1] != "" || off+2 >= len(all) || all[off+1] != "" || leadingSpace(all[off+2]) != "" {
+		return false
 	}
 
+	line = strings.TrimSpace(line)
+
 	// a heading must start with an uppercase letter
 	r, _ := utf8.DecodeRuneInString(line)
 	if !unicode.IsLetter(r) || !unicode.IsUpper(r) {
-		return ""
+		return false
 	}
 
 	// it must end in a letter or digit:
 	r, _ = utf8.DecodeLastRuneInString(line)
 	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
-		return ""
+		return false
 	}
 
-	// exclude lines with illegal characters
-	if strings.IndexAny(line, ",.;:!?+*/=()[]{}_^°&§~%#@<\">\\") >= 0 {
-		return ""
+	// exclude lines with illegal characters. we allow "(),"
+	if strings.ContainsAny(line, ";:!?+*/=[]{}_^°&§~%#@<\">\\") {
+		return false
 	}
 
 	// allow "'" for possessive "'s" only
 	for b := line; ; {
-		i := strings.IndexRune(b, '\'')
+		i := strings.IndexByte(b, '\'')
 		if i < 0 {
 			break
 		}
-		if i+1 >= len(b) || b[i+1] != 's' || (i+2 < len(b) && b[i+2] != ' ') {
-			return "" // not followed by "s "
+		b = b[i+1:]
+		if b != "s" && !strings.HasPrefix(b, "s ") {
+			return false // ' not followed by s and then end-of-word
+		}
+	}
+
+	// allow "." when followed by non-space
+	for b := line; ; {
+		i := strings.IndexByte(b, '.')
+		if i < 0 {
+			break
+		}
+		b = b[i+1:]
+		if b == "" || strings.HasPrefix(b, " ") {
+			return false // not followed by non-space
+		}
+	}
+
+	return true
+}
+
+// isHeading reports whether line is a new-style section heading.
+func isHeading(line string) bool {
+	return len(line) >= 2 &&
+		line[0] == '#' &&
+		(line[1] == ' ' || line[1] == '\t') &&
+		strings.TrimSpace(line) != "#"
+}
+
+// listMarker parses the line as beginning with a list marker.
+// If it can do that, it returns the numeric marker ("" for a bullet list),
+// the rest of the line, and ok == true.
+// Otherwise, it returns "", "", false.
+func listMarker(line string) (num, rest string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", false
+	}
+
+	// Can we find a marker?
+	if r, n := utf8.DecodeRuneInString(line); r == '•' || r == '*' || r == '+' || r == '-' {
+		num, rest = "", line[n:]
+	} else if '0' <= line[0] && line[0] <= '9' {
+		n := 1
+		for n < len(line) && '0' <= line[n] && line[n] <= '9' {
+			n++
+		}
+		if n >= len(line) || (line[n] != '.' && line[n] != ')') {
+			return "", "", false
+		}
+		num, rest = line[:n], line[n+1:]
+	} else {
+		return "", "", false
+	}
+
+	if !indented(rest) || strings.TrimSpace(rest) == "" {
+		return "", "", false
+	}
+
+	return num, rest, true
+}
+
+// isList reports whether the line is the first line of a list,
+// meaning starts with a list marker after any indentation.
+// (The caller is responsible for checking the line is indented, as appropriate.)
+func isList(line string) bool {
+	_, _, ok := listMarker(line)
+	return ok
+}
+
+// A span represents a single span of comment lines (lines[start:end])
+// of an identified kind (code, heading, paragraph, and so on).
+type span struct {
+	start int
+	end   int
+	kind  spanKind
+}
+
+// A spanKind describes the kind of span.
+type spanKind int
+
+const (
+	_ spanKind = iota
+	spanCode
+	spanHeading
+	spanList
+	spanOldHeading
+	spanPara
+)
+
+func parseSpans(lines []string) []span {
+	var spans []span
+
+	// The loop may process a line twice: once as unindented
+	// and again forced indented. So the maximum expected
+	// number of iterations is 2*len(lines).
+	watchdog := 2 * len(lines)
+
+	i := 0
+	forceIndent := 0
+Spans:
+	for {
+		// Skip blank lines.
+		for i < len(lines) && lines[i] == "" {
+			i++
+		}
+		if i >= len(lines) {
+			break
+		}
+		if watchdog--; watchdog < 0 {
+			panic("goutil: internal error: DocParse not making progress")
 		}
-		b = b[i+2:]
+
+		var kind spanKind
+		start := i
+		end := i
+		if i < forceIndent || indented(lines[i]) {
+			// Indented (or force indented).
+			// Ends before next unindented. (Blank lines are OK.)
+			// If this is an unindented list that we are heuristically treating as indented,
+			// then accept unindented list item lines up to the first blank lines.
+			unindentedListOK := isList(lines[i]) && i < forceIndent
+			i++
+			for i < len(lines) && (lines[i] == "" || i < forceIndent || indented(lines[i]) || (unindentedListOK && isList(lines[i]))) {
+				if lines[i] == "" {
+					unindentedListOK = false
+				}
+				i++
+			}
+
+			// Drop trailing blank lines.
+			end = i
+			for end > start && lines[end-1] == "" {
+				end--
+			}
+
+			// If indented lines are followed (without a blank line)
+			// by an unindented line ending in a brace,
+			// take that one line too, as it is most likely the end
+			// of unindented code.
+			if end < len(lines) && strings.HasPrefix(lines[end], "}") {
+				end++
+			}
+
+			if isList(lines[start]) {
+				kind = spanList
+			} else {
+				kind = spanCode
+			}
+		} else {
+			// Unindented. Ends at next blank or indented line.
+			i++
+			for i < len(lines) && lines[i] != "" && !indented(lines[i]) {
+				i++
+			}
+			end = i
+
+			// If unindented lines are followed (without a blank line)
+			// by an indented line that would start a code block,
+			// check whether the final unindented lines
+			// should be left for the indented section,
+			// as with unindented code or unindented lists.
+			if i < len(lines) && lines[i] != "" && !isList(lines[i]) {
+				switch {
+				case isList(lines[i-1]):
+					// Leave all the unindented list items.
+					forceIndent = end
+					end--
+					for end > start && isList(lines[end-1]) {
+						end--
+					}
+
+				case strings.HasSuffix(lines[i-1], "{") || strings.HasSuffix(lines[i-1], `\`):
+					// Probably the start of a misindented code block.
+					forceIndent = end
+					end--
+				}
+
+				if start == end && forceIndent > start {
+					i = start
+					continue Spans
+				}
+			}
+
+			// Span is either paragraph or heading.
+			if end-start == 1 && isHeading(lines[start]) {
+				kind = spanHeading
+			} else if end-start == 1 && isOldHeading(lines[start], lines, start) {
+				kind = spanOldHeading
+			} else {
+				kind = spanPara
+			}
+		}
+
+		spans = append(spans, span{start, end, kind})
+		i = end
 	}
 
-	return line
+	return spans
 }
 
 type op int
@@ -102,90 +324,366 @@ const (
 	Head
 	//Pre marks a block as pre-formatted text, ie code.
 	Pre
+	//List marks a block as a bulleted or numbered list.
+	List
 )
 
 //A Block is a section of documentation parsed in the godoc format.
-//Each block is either a paragraph (Para), a header (Head), or section
-//of pre-formatted text (Pre), most likely code.
+//Each block is either a paragraph (Para), a header (Head), a section
+//of pre-formatted text (Pre), most likely code, or a list (List).
+//
+//The Lines of a Para or Pre end in a newline. A Head has one Line,
+//without a newline. The Lines of a List are all the lines of the list,
+//unindented, so that it may be treated as a Pre if lists are not supported,
+//and its items are in Items.
 type Block struct {
 	Kind  op
 	Lines []string
+	//Items of a List.
+	Items []Item
+	//Spaced is set for a List whose items are separated by blank lines.
+	Spaced bool
+	//Links are the links in the text of a Para, in order.
+	Links []Link
+	//Spans are the inline spans of each of the Lines of a Para,
+	//which cover the line, excluding its newline.
+	Spans [][]Span
+}
+
+//An Item is an item of a List Block.
+type Item struct {
+	//Number is the number of a numbered list item, such as "1",
+	//or "" for an item of a bulleted list.
+	Number string
+	//Blocks are the paragraphs of the item, each a Para.
+	Blocks []Block
+}
+
+//A LinkDef is a link definition, a line of the form
+//	[Text]: URL
+//in a block of only link definitions, which are not part of the text.
+type LinkDef struct {
+	Text, URL string
+	//Used is set if the text of the documentation links to the definition.
+	Used bool
+}
+
+//A DocLink is a reference to the documentation of a package or one of
+//its declarations, such as [Block], [Package.Parse], [io], [io.Reader], or
+//[go/build.Context.Import]. The package is either a package of the
+//standard library or a complete import path beginning with a domain name.
+type DocLink struct {
+	//ImportPath is the import path of the package, or "" for
+	//the package being documented.
+	ImportPath string
+	//Recv is the type of a method or field, or "".
+	Recv string
+	//Name is the name of the declaration, or "" for a package.
+	Name string
+}
+
+//A Link is a bracketed link in text, either to a LinkDef, as in [Go home page],
+//or to documentation, as in [io.Reader].
+type Link struct {
+	//Text is the text within the brackets.
+	Text string
+	//URL is the URL of the LinkDef.
+	URL string
+	//Doc is set for a link to documentation.
+	Doc *DocLink
+}
+
+//Doc is documentation parsed by ParseDoc.
+type Doc struct {
+	Blocks []Block
+	//Links are the link definitions, in order.
+	Links []*LinkDef
 }
 
 //DocParse takes text blocks from comments (from go/doc) and parses it into
 //an intermediary format so that it may be formatted as desired.
+//
+//It is the same as ParseDoc but discards the link definitions.
 func DocParse(text string) []Block {
-	var (
-		out  []Block
-		para []string
+	return ParseDoc(text).Blocks
+}
 
-		lastWasBlank   = false
-		lastWasHeading = false
-	)
+//ParseDoc parses the text of a doc comment, as from go/doc, into Blocks,
+//according to the syntax of Go 1.19 doc comments, described at
+//https://go.dev/doc/comment, which includes # headings, lists, links, and
+//doc links, as well as the older heuristic for headings.
+func ParseDoc(text string) *Doc {
+	doc := &Doc{}
+	lines := unindent(strings.Split(text, "\n"))
 
-	close := func() {
-		if para != nil {
-			out = append(out, Block{Para, para})
-			para = nil
+	defs := map[string]*LinkDef{}
+	for _, s := range parseSpans(lines) {
+		switch s.kind {
+		case spanList:
+			doc.Blocks = append(doc.Blocks, listBlock(lines[s.start:s.end], defs, doc))
+		case spanCode:
+			doc.Blocks = append(doc.Blocks, Block{Kind: Pre, Lines: withNewlines(unindent(lines[s.start:s.end]))})
+		case spanOldHeading:
+			doc.Blocks = append(doc.Blocks, Block{Kind: Head, Lines: []string{strings.TrimSpace(lines[s.start])}})
+		case spanHeading:
+			doc.Blocks = append(doc.Blocks, Block{Kind: Head, Lines: []string{strings.TrimSpace(lines[s.start][1:])}})
+		case spanPara:
+			if b, ok := paraBlock(lines[s.start:s.end], defs, doc); ok {
+				doc.Blocks = append(doc.Blocks, b)
+			}
 		}
 	}
 
-	lines := strings.SplitAfter(text, "\n")
-	unindent(lines)
-	for i := 0; i < len(lines); {
-		line := lines[i]
-		if isBlank(line) {
-			// close paragraph
-			close()
-			i++
-			lastWasBlank = true
+	//links may refer to definitions that follow them.
+	for i := range doc.Blocks {
+		doc.Blocks[i].inline(defs)
+	}
+	return doc
+}
+
+func withNewlines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = line + "\n"
+	}
+	return out
+}
+
+//paraBlock returns a Para of the lines, or, if the lines are
+//link definitions, adds them to defs and doc and returns false.
+func paraBlock(lines []string, defs map[string]*LinkDef, doc *Doc) (Block, bool) {
+	var ds []*LinkDef
+	for _, line := range lines {
+		def, ok := parseLinkDef(line)
+		if !ok {
+			return Block{Kind: Para, Lines: withNewlines(lines)}, true
+		}
+		ds = append(ds, def)
+	}
+	for _, def := range ds {
+		doc.Links = append(doc.Links, def)
+		if defs[def.Text] == nil {
+			defs[def.Text] = def
+		}
+	}
+	return Block{}, false
+}
+
+// parseLinkDef parses a single link definition line:
+//
+//	[text]: url
+//
+// It returns the link definition and whether the line was well formed.
+func parseLinkDef(line string) (*LinkDef, bool) {
+	if line == "" || line[0] != '[' {
+		return nil, false
+	}
+	i := strings.Index(line, "]:")
+	if i < 0 || i+3 >= len(line) || (line[i+2] != ' ' && line[i+2] != '\t') {
+		return nil, false
+	}
+
+	text := line[1:i]
+	url := strings.TrimSpace(line[i+3:])
+	j := strings.Index(url, "://")
+	if j < 0 || !isScheme(url[:j]) {
+		return nil, false
+	}
+	return &LinkDef{Text: text, URL: url}, true
+}
+
+func isScheme(s string) bool {
+	switch s {
+	case "file", "ftp", "gopher", "http", "https", "mailto", "nntp":
+		return true
+	}
+	return false
+}
+
+//listBlock returns a List of the lines.
+func listBlock(lines []string, defs map[string]*LinkDef, doc *Doc) Block {
+	num, _, _ := listMarker(lines[0])
+	b := Block{Kind: List, Lines: withNewlines(unindent(lines))}
+	var text []string
+	flush := func() {
+		if len(b.Items) > 0 && len(text) > 0 {
+			if p, ok := paraBlock(text, defs, doc); ok {
+				it := &b.Items[len(b.Items)-1]
+				it.Blocks = append(it.Blocks, p)
+			}
+		}
+		text = nil
+	}
+
+	for _, line := range lines {
+		if n, after, ok := listMarker(line); ok && (n != "") == (num != "") {
+			flush()
+			b.Items = append(b.Items, Item{Number: n})
+			line = after
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			b.Spaced = true
+			flush()
 			continue
 		}
-		if indentLen(line) > 0 {
-			// close paragraph
-			close()
+		text = append(text, line)
+	}
+	flush()
+	return b
+}
+
+//a bracketed link in text, which is text[start:end], including the brackets.
+type linkPos struct {
+	start, end int
+	link       Link
+}
 
-			// count indented or blank lines
-			j := i + 1
-			for j < len(lines) && (isBlank(lines[j]) || indentLen(lines[j]) > 0) {
-				j++
+//findLinks finds the bracketed links in text.
+func findLinks(text string, defs map[string]*LinkDef) (out []linkPos) {
+	start := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			start = i
+		case ']':
+			if start < 0 {
+				continue
 			}
-			// but not trailing blank lines
-			for j > i && isBlank(lines[j-1]) {
-				j--
+			inner := text[start+1 : i]
+			key := strings.Map(func(r rune) rune {
+				if r == '\n' || r == '\t' {
+					return ' '
+				}
+				return r
+			}, inner)
+			if def, ok := defs[key]; ok {
+				def.Used = true
+				out = append(out, linkPos{start, i + 1, Link{Text: inner, URL: def.URL}})
+			} else if dl, ok := parseDocLink(inner, text[:start], text[i+1:]); ok {
+				out = append(out, linkPos{start, i + 1, Link{Text: inner, Doc: dl}})
 			}
-			pre := lines[i:j]
-			i = j
-
-			unindent(pre)
+			start = -1
+		}
+	}
+	return
+}
 
-			// put those lines in a pre block
-			out = append(out, Block{Pre, pre})
-			lastWasHeading = false
-			continue
+// parseDocLink parses text, which was found inside [ ] brackets,
+// as a doc link if possible.
+// The before and after strings are the text before the [ and after the ].
+// Doc links must be preceded and followed by punctuation, spaces, tabs,
+// or the start or end of a line, to avoid mistaking things like
+// map[ast.Expr]TypeAndValue for links.
+func parseDocLink(text, before, after string) (*DocLink, bool) {
+	if before != "" {
+		r, _ := utf8.DecodeLastRuneInString(before)
+		if !unicode.IsPunct(r) && r != ' ' && r != '\t' && r != '\n' {
+			return nil, false
+		}
+	}
+	if after != "" {
+		r, _ := utf8.DecodeRuneInString(after)
+		if !unicode.IsPunct(r) && r != ' ' && r != '\t' && r != '\n' {
+			return nil, false
 		}
+	}
+	text = strings.TrimPrefix(text, "*")
+	pkg, name, ok := splitDocName(text)
+	var recv string
+	if ok {
+		pkg, recv, _ = splitDocName(pkg)
+	}
+	if pkg != "" && !docLinkPkg(pkg) {
+		return nil, false
+	}
+	if pkg == "" && name == "" {
+		return nil, false
+	}
+	return &DocLink{ImportPath: pkg, Recv: recv, Name: name}, true
+}
+
+// If text is of the form before.Name, where Name is a capitalized Go identifier,
+// then splitDocName returns before, name, true.
+// Otherwise it returns text, "", false.
+func splitDocName(text string) (before, name string, foundDot bool) {
+	i := strings.LastIndex(text, ".")
+	name = text[i+1:]
+	if !isName(name) {
+		return text, "", false
+	}
+	if i >= 0 {
+		before = text[:i]
+	}
+	return before, name, true
+}
+
+// isName reports whether s is a capitalized Go identifier (like Name).
+func isName(s string) bool {
+	t, ok := identPrefix(s)
+	if !ok || t != s {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsUpper(r)
+}
 
-		if lastWasBlank && !lastWasHeading && i+2 < len(lines) &&
-			isBlank(lines[i+1]) && !isBlank(lines[i+2]) && indentLen(lines[i+2]) == 0 {
-			// current line is non-blank, surrounded by blank lines
-			// and the next non-blank line is not indented: this
-			// might be a heading.
-			if head := heading(line); head != "" {
-				close()
-				out = append(out, Block{Head, []string{head}})
-				i += 2
-				lastWasHeading = true
+// identPrefix checks whether s begins with a Go identifier.
+// If so, it returns the identifier, which is a prefix of s, and ok == true.
+// Otherwise it returns "", false.
+func identPrefix(s string) (id string, ok bool) {
+	n := 0
+	for n < len(s) {
+		if c := s[n]; c < utf8.RuneSelf {
+			if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || (n > 0 && '0' <= c && c <= '9') {
+				n++
 				continue
 			}
+			break
+		}
+		r, nr := utf8.DecodeRuneInString(s[n:])
+		if unicode.IsLetter(r) || (n > 0 && unicode.IsDigit(r)) {
+			n += nr
+			continue
 		}
+		break
+	}
+	return s[:n], n > 0
+}
 
-		// open paragraph
-		lastWasBlank = false
-		lastWasHeading = false
-		para = append(para, lines[i])
-		i++
+var (
+	stdmux  = new(sync.Mutex)
+	stdpkgs = map[string]bool{}
+)
+
+//isStdPkg reports whether path is a package in the standard library.
+func isStdPkg(path string) bool {
+	stdmux.Lock()
+	defer stdmux.Unlock()
+	if std, ok := stdpkgs[path]; ok {
+		return std
 	}
-	close()
+	bp, err := defaultctx.Import(path, "", build.FindOnly)
+	std := err == nil && bp.Goroot
+	stdpkgs[path] = std
+	return std
+}
 
-	return out
+//docLinkPkg reports whether pkg may be the package of a doc link.
+func docLinkPkg(pkg string) bool {
+	elems := strings.Split(pkg, "/")
+	for _, elem := range elems {
+		if elem == "" || elem == "." || elem == ".." {
+			return false
+		}
+		for _, c := range elem {
+			if !(c == '.' || c == '-' || c == '_' || c == '~' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+				return false
+			}
+		}
+	}
+	if strings.Contains(elems[0], ".") {
+		return true
+	}
+	return isStdPkg(pkg)
 }
@@ -0,0 +1,121 @@
+package goutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func nospans(b *Block) {
+	b.Spans = nil
+	for i := range b.Items {
+		for j := range b.Items[i].Blocks {
+			nospans(&b.Items[i].Blocks[j])
+		}
+	}
+}
+
+func TestParseDoc(t *testing.T) {
+	const text = `Package x does things.
+
+Overview
+
+See [io.Reader], [Name], [Go home], [T.M] and
+a[i] and [note] and [github.com/a/b.C].
+
+# New heading
+
+  - one item
+    continued
+  - two
+
+Text.
+
+ 1. first
+
+ 2. second
+
+More.
+
+	code
+
+[Go home]: https://go.dev
+`
+	expected := []Block{
+		{Kind: Para, Lines: []string{"Package x does things.\n"}},
+		{Kind: Head, Lines: []string{"Overview"}},
+		{Kind: Para, Lines: []string{
+			"See [io.Reader], [Name], [Go home], [T.M] and\n",
+			"a[i] and [note] and [github.com/a/b.C].\n",
+		}, Links: []Link{
+			{Text: "io.Reader", Doc: &DocLink{ImportPath: "io", Name: "Reader"}},
+			{Text: "Name", Doc: &DocLink{Name: "Name"}},
+			{Text: "Go home", URL: "https://go.dev"},
+			{Text: "T.M", Doc: &DocLink{Recv: "T", Name: "M"}},
+			{Text: "github.com/a/b.C", Doc: &DocLink{ImportPath: "github.com/a/b", Name: "C"}},
+		}},
+		{Kind: Head, Lines: []string{"New heading"}},
+		{Kind: List, Lines: []string{"- one item\n", "  continued\n", "- two\n"}, Items: []Item{
+			{Blocks: []Block{{Kind: Para, Lines: []string{"one item\n", "continued\n"}}}},
+			{Blocks: []Block{{Kind: Para, Lines: []string{"two\n"}}}},
+		}},
+		{Kind: Para, Lines: []string{"Text.\n"}},
+		{Kind: List, Lines: []string{"1. first\n", "\n", "2. second\n"}, Spaced: true, Items: []Item{
+			{Number: "1", Blocks: []Block{{Kind: Para, Lines: []string{"first\n"}}}},
+			{Number: "2", Blocks: []Block{{Kind: Para, Lines: []string{"second\n"}}}},
+		}},
+		{Kind: Para, Lines: []string{"More.\n"}},
+		{Kind: Pre, Lines: []string{"code\n"}},
+	}
+
+	d := ParseDoc(text)
+	if len(d.Blocks) != len(expected) {
+		t.Fatalf("expected %d blocks, got %d: %#v", len(expected), len(d.Blocks), d.Blocks)
+	}
+	for i, b := range d.Blocks {
+		//spans are tested by TestSpans
+		nospans(&b)
+		if !reflect.DeepEqual(b, expected[i]) {
+			t.Errorf("block %d: expected\n%#v\ngot\n%#v", i, expected[i], b)
+		}
+	}
+	if len(d.Links) != 1 || *d.Links[0] != (LinkDef{"Go home", "https://go.dev", true}) {
+		t.Errorf("unexpected link definitions %v", d.Links)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	const text = `See https://go.dev/doc, [io.Reader], and [the
+Go home page]. Call Package.Parse or Decls, not Foo.Bar.
+
+[the Go home page]: https://go.dev
+`
+	d := ParseDoc(text)
+	d.MarkIdents(Exact("Package.Parse", "Decls", "Bar"))
+	expected := [][]Span{
+		{
+			{Kind: PlainSpan, Start: 0, End: 4, Text: "See "},
+			{Kind: URLSpan, Start: 4, End: 22, Text: "https://go.dev/doc", URL: "https://go.dev/doc"},
+			{Kind: PlainSpan, Start: 22, End: 24, Text: ", "},
+			{Kind: LinkSpan, Start: 24, End: 35, Text: "io.Reader", Doc: &DocLink{ImportPath: "io", Name: "Reader"}},
+			{Kind: PlainSpan, Start: 35, End: 41, Text: ", and "},
+			{Kind: LinkSpan, Start: 41, End: 45, Text: "the", URL: "https://go.dev"},
+		},
+		{
+			{Kind: LinkSpan, Start: 0, End: 13, Text: "Go home page", URL: "https://go.dev"},
+			{Kind: PlainSpan, Start: 13, End: 20, Text: ". Call "},
+			{Kind: IdentSpan, Start: 20, End: 33, Text: "Package.Parse"},
+			{Kind: PlainSpan, Start: 33, End: 37, Text: " or "},
+			{Kind: IdentSpan, Start: 37, End: 42, Text: "Decls"},
+			{Kind: PlainSpan, Start: 42, End: 52, Text: ", not Foo."},
+			{Kind: IdentSpan, Start: 52, End: 55, Text: "Bar"},
+			{Kind: PlainSpan, Start: 55, End: 56, Text: "."},
+		},
+	}
+	if len(d.Blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(d.Blocks))
+	}
+	spans := d.Blocks[0].Spans
+	if !reflect.DeepEqual(spans, expected) {
+		t.Errorf("expected\n%+v\ngot\n%+v", expected, spans)
+	}
+}
@@ -0,0 +1,377 @@
+package goutil
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//pkgGoDev returns the URL of the documentation of the package with the
+//import path path on pkg.go.dev.
+func pkgGoDev(path string) string {
+	return "https://pkg.go.dev/" + path
+}
+
+//docLinkURL returns the URL of a doc link: an anchor for a declaration
+//in the same package, or the package's page, as given by pkgURL.
+func docLinkURL(l *DocLink, pkgURL func(string) string) string {
+	anchor := l.Name
+	if l.Recv != "" {
+		anchor = l.Recv + "." + l.Name
+	}
+	if l.ImportPath == "" {
+		return "#" + anchor
+	}
+	url := pkgURL(l.ImportPath)
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	return url
+}
+
+//fileImports returns the imports of each file of pkg, by filename, as a map
+//from import path to the name the import is given, or "" if none.
+//Blank and dot imports are omitted.
+func fileImports(pkg *ast.Package) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for name, f := range pkg.Files {
+		imports := map[string]string{}
+		for _, is := range f.Imports {
+			path, err := strconv.Unquote(is.Path.Value)
+			if err != nil {
+				continue
+			}
+			local := ""
+			if is.Name != nil {
+				local = is.Name.Name
+				if local == "_" || local == "." {
+					continue
+				}
+			}
+			imports[path] = local
+		}
+		out[name] = imports
+	}
+	return out
+}
+
+//importNames returns the names the packages imported by file, a file of
+//p.Doc, are known by there, mapped to their import paths. A package
+//imported without a name has the name of the Package of deps with its
+//import path or, if there is none, is taken to be named for the last
+//element of its path. If file is "", the imports of every file are
+//combined, omitting any name given to different packages in different
+//files.
+func (p *Package) importNames(file string, deps Packages) map[string]string {
+	files := p.docImports
+	if file != "" {
+		files = map[string]map[string]string{file: p.docImports[file]}
+	}
+	names := map[string]string{}
+	ambiguous := map[string]bool{}
+	for _, imports := range files {
+		for importPath, name := range imports {
+			if name == "" {
+				name = path.Base(importPath)
+				for _, dep := range deps {
+					if dep.Build.ImportPath == importPath {
+						name = dep.Build.Name
+					}
+				}
+			}
+			if old, ok := names[name]; ok && old != importPath {
+				ambiguous[name] = true
+			}
+			names[name] = importPath
+		}
+	}
+	for name := range ambiguous {
+		delete(names, name)
+	}
+	return names
+}
+
+//mapSpans replaces the spans of every line of the Paras of bs,
+//including those in lists, with f of them.
+func mapSpans(bs []Block, f func([]Span) []Span) {
+	for i := range bs {
+		for j := range bs[i].Items {
+			mapSpans(bs[i].Items[j].Blocks, f)
+		}
+		for j, spans := range bs[i].Spans {
+			bs[i].Spans[j] = f(spans)
+		}
+	}
+}
+
+//qualify returns the link to the documentation of id, a qualified
+//identifier such as fmt.Println, if the qualifier is one of the names of
+//imports and the rest is one of the names of the documentation of the
+//package it imports, as given by names.
+func qualify(id string, imports map[string]string, names map[string]ExactMatcher) *DocLink {
+	i := strings.IndexByte(id, '.')
+	if i < 0 {
+		return nil
+	}
+	importPath, ok := imports[id[:i]]
+	if !ok || !names[importPath][id[i+1:]] {
+		return nil
+	}
+	l := &DocLink{ImportPath: importPath, Name: id[i+1:]}
+	if j := strings.IndexByte(l.Name, '.'); j >= 0 {
+		l.Recv, l.Name = l.Name[:j], l.Name[j+1:]
+	}
+	return l
+}
+
+//link returns spans with each IdentSpan not matched by local either given
+//the link returned by qualify, if any, or made plain and joined to the plain
+//text around it. A linked identifier in brackets, as in [fmt.Println],
+//becomes a LinkSpan.
+func link(spans []Span, local StringMatcher, qualify func(string) *DocLink) (out []Span) {
+	for i := 0; i < len(spans); i++ {
+		s := spans[i]
+		if s.Kind == IdentSpan && !local.MatchString(s.Text) {
+			if s.Doc = qualify(s.Text); s.Doc == nil {
+				s.Kind = PlainSpan
+			}
+		}
+		if n := len(out); s.Doc != nil && n > 0 && i+1 < len(spans) {
+			prev, next := &out[n-1], &spans[i+1]
+			if prev.Kind == PlainSpan && strings.HasSuffix(prev.Text, "[") && next.Kind == PlainSpan && strings.HasPrefix(next.Text, "]") {
+				s.Kind = LinkSpan
+				s.Start--
+				s.End++
+				prev.End--
+				prev.Text = prev.Text[:len(prev.Text)-1]
+				next.Start++
+				next.Text = next.Text[1:]
+				if prev.Text == "" {
+					out = out[:n-1]
+				}
+			}
+		}
+		if s.Kind == PlainSpan && s.Text == "" {
+			continue
+		}
+		if n := len(out); n > 0 && s.Kind == PlainSpan && out[n-1].Kind == PlainSpan {
+			out[n-1].End = s.End
+			out[n-1].Text += s.Text
+			continue
+		}
+		out = append(out, s)
+	}
+	return
+}
+
+//An indexEntry is a line of the index of a package's documentation.
+type indexEntry struct {
+	//depth is 0 for the package's declarations and 1 for the
+	//functions and methods of a type.
+	depth        int
+	anchor, text string
+}
+
+//A docWriter renders each part of the documentation of a package in
+//some format, as directed by renderDoc.
+type docWriter interface {
+	//heading writes a heading, level 1 being the package's name.
+	//If anchor is not "", it is the target of links to the heading.
+	heading(level int, anchor, text string)
+	//decl writes the source of a declaration.
+	decl(src string)
+	//doc writes parsed documentation.
+	doc(d *Doc)
+	//index writes the index of the package's declarations.
+	index(entries []indexEntry)
+}
+
+//docDecl returns the source of a declaration from p.Doc,
+//without the body of a function. The printer includes the comments
+//of the fields and specs that remain after go/doc's filtering, and notes
+//where fields have been filtered.
+func (p *Package) docDecl(d ast.Decl) string {
+	if fd, ok := d.(*ast.FuncDecl); ok {
+		c := *fd
+		c.Body = nil
+		d = &c
+	}
+	var b bytes.Buffer
+	conf := &printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	conf.Fprint(&b, p.docFileSet, d)
+	return b.String()
+}
+
+//docNames returns the names in p.Doc that identifiers in its
+//documentation are linked to: the functions, types, and methods.
+func docNames(d *doc.Package) (names []string) {
+	funcs := func(fs []*doc.Func) {
+		for _, f := range fs {
+			names = append(names, f.Name)
+		}
+	}
+	funcs(d.Funcs)
+	for _, t := range d.Types {
+		names = append(names, t.Name)
+		funcs(t.Funcs)
+		for _, m := range t.Methods {
+			names = append(names, t.Name+"."+m.Name)
+		}
+	}
+	return
+}
+
+//funcTitle returns the title of the documentation of f,
+//such as func (p *Package) Parse.
+func funcTitle(f *doc.Func) string {
+	if f.Recv == "" {
+		return "func " + f.Name
+	}
+	recv := f.Recv
+	if r := f.Decl.Recv; r != nil && len(r.List) > 0 && len(r.List[0].Names) > 0 {
+		recv = r.List[0].Names[0].Name + " " + recv
+	}
+	return "func (" + recv + ") " + f.Name
+}
+
+//funcAnchor returns the anchor of f, which is its name,
+//qualified by its type for a method.
+func funcAnchor(f *doc.Func) string {
+	if f.Recv == "" {
+		return f.Name
+	}
+	return strings.TrimPrefix(f.Recv, "*") + "." + f.Name
+}
+
+//renderDoc directs dw through the documentation of p, in the same
+//order as godoc: the overview, an index, then the constants, variables,
+//functions, and types, each followed by its own functions and methods.
+//Only qualified identifiers are marked in the documentation: those naming
+//the methods of the package, such as Package.Parse, and those naming the
+//declarations of the Packages of deps whose documentation has been parsed,
+//qualified by the name they are imported as, which are linked to that
+//documentation. A bare word, such as the Package of "Package goutil is",
+//is too often not the declaration of the same name to be marked; such
+//a declaration is linked only by a doc link, such as [Package].
+func (p *Package) renderDoc(dw docWriter, deps Packages) error {
+	d := p.Doc
+	if d == nil {
+		return errors.New("Package documentation has not been parsed")
+	}
+	words := ExactMatcher{}
+	for _, name := range docNames(d) {
+		if strings.Contains(name, ".") {
+			words[name] = true
+		}
+	}
+	names := map[string]ExactMatcher{}
+	for _, dep := range deps {
+		if dep.Doc != nil {
+			names[dep.Build.ImportPath] = Exact(docNames(dep.Doc)...)
+		}
+	}
+	//the documentation of a declaration does not link to itself, and an
+	//identifier qualified by the name of a package imported by the file of
+	//the declaration, such as build.Context, is not taken for one of the
+	//package's own.
+	parse := func(text, self string, pos token.Pos) *Doc {
+		file := ""
+		if pos.IsValid() {
+			file = p.docFileSet.Position(pos).Filename
+		}
+		imports := p.importNames(file, deps)
+		var m StringMatcher = All(words, Not(Exact(self)))
+		for name := range imports {
+			m = Any(m, PrefixMatcher(name+"."))
+		}
+		pd := ParseDoc(text)
+		pd.MarkIdents(m)
+		mapSpans(pd.Blocks, func(spans []Span) []Span {
+			return link(spans, words, func(id string) *DocLink {
+				return qualify(id, imports, names)
+			})
+		})
+		return pd
+	}
+	//the declaration of a function, on one line for the index.
+	sig := func(f *doc.Func) string {
+		return strings.Join(strings.Fields(p.docDecl(f.Decl)), " ")
+	}
+
+	dw.heading(1, "", "package "+d.Name)
+	if d.Name != "main" {
+		dw.decl(`import "` + d.ImportPath + `"`)
+	}
+	dw.doc(parse(d.Doc, "", token.NoPos))
+
+	var index []indexEntry
+	if len(d.Consts) > 0 {
+		index = append(index, indexEntry{0, "pkg-constants", "Constants"})
+	}
+	if len(d.Vars) > 0 {
+		index = append(index, indexEntry{0, "pkg-variables", "Variables"})
+	}
+	for _, f := range d.Funcs {
+		index = append(index, indexEntry{0, funcAnchor(f), sig(f)})
+	}
+	for _, t := range d.Types {
+		index = append(index, indexEntry{0, t.Name, "type " + t.Name})
+		for _, f := range t.Funcs {
+			index = append(index, indexEntry{1, funcAnchor(f), sig(f)})
+		}
+		for _, f := range t.Methods {
+			index = append(index, indexEntry{1, funcAnchor(f), sig(f)})
+		}
+	}
+	if len(index) == 0 {
+		return nil
+	}
+	dw.heading(2, "pkg-index", "Index")
+	dw.index(index)
+
+	values := func(vs []*doc.Value) {
+		for _, v := range vs {
+			dw.decl(p.docDecl(v.Decl))
+			dw.doc(parse(v.Doc, "", v.Decl.Pos()))
+		}
+	}
+	funcs := func(fs []*doc.Func) {
+		for _, f := range fs {
+			dw.heading(3, funcAnchor(f), funcTitle(f))
+			dw.decl(p.docDecl(f.Decl))
+			dw.doc(parse(f.Doc, funcAnchor(f), f.Decl.Pos()))
+		}
+	}
+
+	if len(d.Consts) > 0 {
+		dw.heading(2, "pkg-constants", "Constants")
+		values(d.Consts)
+	}
+	if len(d.Vars) > 0 {
+		dw.heading(2, "pkg-variables", "Variables")
+		values(d.Vars)
+	}
+	if len(d.Funcs) > 0 {
+		dw.heading(2, "pkg-functions", "Functions")
+		funcs(d.Funcs)
+	}
+	if len(d.Types) > 0 {
+		dw.heading(2, "pkg-types", "Types")
+	}
+	for _, t := range d.Types {
+		dw.heading(3, t.Name, "type "+t.Name)
+		dw.decl(p.docDecl(t.Decl))
+		dw.doc(parse(t.Doc, t.Name, t.Decl.Pos()))
+		values(t.Consts)
+		values(t.Vars)
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+	return nil
+}
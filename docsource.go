@@ -0,0 +1,91 @@
+package goutil
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//ignoredDocFiles returns the files ignored by p.Build that hold package
+//documentation: doc.go, as when it is excluded by a build constraint of
+//ignore, if it is of the same package, and any file whose package clause
+//is "package documentation".
+//
+//The files ignored need not be meant to parse, so those that do not
+//are skipped.
+func (p *Package) ignoredDocFiles() (files []string) {
+	//a package named documentation documents itself.
+	if p.Build.Name == "documentation" {
+		return nil
+	}
+	for _, name := range p.Build.IgnoredGoFiles {
+		f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(p.Build.Dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		if f.Name.Name == "documentation" || (name == "doc.go" && f.Name.Name == p.Build.Name) {
+			files = append(files, name)
+		}
+	}
+	return
+}
+
+//docSources sets p.DocSources and, if any of the doc files of p has
+//a package comment, replaces the Doc of d with them. Otherwise, the
+//sources are own, the files of the package with package comments.
+//
+//An explicit doc file that cannot be read is an error, but an ignored file
+//that does not parse is skipped.
+func (p *Package) docSources(d *doc.Package, own []string) error {
+	files := p.DocFiles
+	explicit := files != nil
+	if !explicit {
+		files = p.ignoredDocFiles()
+	}
+
+	var texts, sources []string
+	fs := token.NewFileSet()
+	for _, name := range files {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(p.Build.Dir, name)
+		}
+		f, err := parser.ParseFile(fs, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			if explicit {
+				return err
+			}
+			continue
+		}
+		if f.Doc != nil {
+			texts = append(texts, f.Doc.Text())
+			sources = append(sources, name)
+		}
+	}
+	if len(sources) > 0 {
+		//go/doc joins the package comments of several files the same way.
+		d.Doc = strings.Join(texts, "\n")
+		p.DocSources = sources
+		return nil
+	}
+
+	p.DocSources = own
+	return nil
+}
+
+//commentedFiles returns the names of the files of pkg with package comments,
+//in the order go/doc reads them. This must be called before go/doc
+//removes the comments from pkg.
+func commentedFiles(pkg *ast.Package) (names []string) {
+	for name, f := range pkg.Files {
+		if f.Doc != nil {
+			names = append(names, filepath.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return
+}
@@ -0,0 +1,91 @@
+package goutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocSources(t *testing.T) {
+	const ignore = "//go:build ignore\n\n"
+	tests := []struct {
+		files    map[string]string
+		docFiles []string
+		doc      string
+		sources  []string
+	}{
+		{
+			files: map[string]string{
+				"a.go": "//Package a is short.\npackage a\n",
+			},
+			doc:     "Package a is short.\n",
+			sources: []string{"a.go"},
+		},
+		{
+			files: map[string]string{
+				"a.go":   "//Package a is short.\npackage a\n",
+				"doc.go": ignore + "//Package a is long.\npackage a\n",
+				"x.go":   "//More.\npackage documentation\n",
+				"y.go":   ignore + "//Not this.\npackage y\n",
+			},
+			doc:     "Package a is long.\n\nMore.\n",
+			sources: []string{"doc.go", "x.go"},
+		},
+		{
+			//an ignored file without a package comment does not override.
+			files: map[string]string{
+				"a.go":   "//Package a is short.\npackage a\n",
+				"doc.go": ignore + "package a\n",
+			},
+			doc:     "Package a is short.\n",
+			sources: []string{"a.go"},
+		},
+		{
+			//an ignored doc.go of another package is not the package's.
+			files: map[string]string{
+				"a.go":   "//Package a is short.\npackage a\n",
+				"doc.go": ignore + "//Package main generates a.\npackage main\n",
+			},
+			doc:     "Package a is short.\n",
+			sources: []string{"a.go"},
+		},
+		{
+			files: map[string]string{
+				"a.go":   "//Package a is short.\npackage a\n",
+				"doc.go": ignore + "//Package a is long.\npackage a\n",
+				"README": "//Package a is explicit.\npackage a\n",
+			},
+			docFiles: []string{"README"},
+			doc:      "Package a is explicit.\n",
+			sources:  []string{"README"},
+		},
+	}
+	for i, test := range tests {
+		dir, err := ioutil.TempDir("", "goutil-docsource")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		for name, src := range test.files {
+			if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		p, err := ImportDir(nil, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.DocFiles = test.docFiles
+		if err = p.ParseDocs(0); err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if p.Doc.Doc != test.doc {
+			t.Errorf("%d: expected doc %q, got %q", i, test.doc, p.Doc.Doc)
+		}
+		if got, want := strings.Join(p.DocSources, " "), strings.Join(test.sources, " "); got != want {
+			t.Errorf("%d: expected sources %q, got %q", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package goutil
+
+import (
+	"strings"
+)
+
+//SpanKind classifies a Span.
+type SpanKind int
+
+const (
+	//PlainSpan marks a span of plain text.
+	PlainSpan SpanKind = iota
+	//URLSpan marks a URL in the text.
+	URLSpan
+	//IdentSpan marks an identifier, as found by MarkIdents.
+	IdentSpan
+	//LinkSpan marks a bracketed Link, to either a LinkDef or documentation.
+	LinkSpan
+)
+
+//A Span is a section of a line of text in a Para.
+type Span struct {
+	Kind SpanKind
+	//Start and End are the byte offsets of the span in its line.
+	//The span of a Link includes its brackets.
+	Start, End int
+	//Text is the text of the span to display, which excludes the brackets
+	//of a Link.
+	Text string
+	//URL is the URL of a URLSpan, or of a LinkSpan to a LinkDef.
+	URL string
+	//Doc is the target of a LinkSpan to documentation,
+	//or of an IdentSpan naming a declaration of another package.
+	Doc *DocLink
+}
+
+//charsets of URLs, from go/doc/comment.
+const (
+	//bytes that can appear in a URL host, like www.example.com or user@[::1]:8080
+	hostChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_@-.[]:"
+	//punctuation that can appear inside a path but not at the end.
+	punctChars = ".,:;?!"
+	//other bytes that can appear in a path.
+	pathChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789$'()*+&#=@~_/-[]{}%"
+)
+
+func in(c byte, chars string) bool {
+	return strings.IndexByte(chars, c) >= 0
+}
+
+// autoURL checks whether s begins with a URL that should be hyperlinked.
+// If so, it returns the URL, which is a prefix of s, and ok == true.
+// Otherwise it returns "", false.
+func autoURL(s string) (url string, ok bool) {
+	//the scheme is 3 to 6 bytes, and this is called at every position
+	//in the text, so only look for :// where it could be.
+	i := -1
+	for j := 3; j <= 6 && j+3 <= len(s); j++ {
+		if s[j:j+3] == "://" {
+			i = j
+			break
+		}
+	}
+	if i < 0 || !isScheme(s[:i]) {
+		return "", false
+	}
+
+	// Scan host part. Must have at least one byte,
+	// and must start and end in non-punctuation.
+	i += 3
+	if i >= len(s) || !in(s[i], hostChars) || in(s[i], punctChars) {
+		return "", false
+	}
+	i++
+	end := i
+	for i < len(s) && in(s[i], hostChars) {
+		if !in(s[i], punctChars) {
+			end = i + 1
+		}
+		i++
+	}
+	i = end
+
+	// Find the longest path we can add to it.
+	// Parens, braces, and brackets are allowed only if they match,
+	// and .,:;?! only if they are not at the end, to avoid
+	// end-of-sentence punctuation.
+	var stk []byte
+	end = i
+Path:
+	for ; i < len(s); i++ {
+		if in(s[i], punctChars) {
+			continue
+		}
+		if !in(s[i], pathChars) {
+			break
+		}
+		switch s[i] {
+		case '(':
+			stk = append(stk, ')')
+		case '{':
+			stk = append(stk, '}')
+		case '[':
+			stk = append(stk, ']')
+		case ')', '}', ']':
+			if len(stk) == 0 || stk[len(stk)-1] != s[i] {
+				break Path
+			}
+			stk = stk[:len(stk)-1]
+		}
+		if len(stk) == 0 {
+			end = i + 1
+		}
+	}
+
+	return s[:end], true
+}
+
+//textSpans appends the spans of plain text and URLs in s,
+//which starts at offset off.
+func textSpans(out []Span, s string, off int) []Span {
+	wrote := 0
+	flush := func(i int) {
+		if wrote < i {
+			out = append(out, Span{Kind: PlainSpan, Start: off + wrote, End: off + i, Text: s[wrote:i]})
+		}
+	}
+	for i := 0; i < len(s); {
+		if url, ok := autoURL(s[i:]); ok {
+			flush(i)
+			out = append(out, Span{Kind: URLSpan, Start: off + i, End: off + i + len(url), Text: url, URL: url})
+			i += len(url)
+			wrote = i
+			continue
+		}
+		//do not look for URLs in the middle of a word.
+		if id, ok := identPrefix(s[i:]); ok {
+			i += len(id)
+			continue
+		}
+		i++
+	}
+	flush(len(s))
+	return out
+}
+
+//inline finds the Links and Spans of b and the blocks of its items.
+func (b *Block) inline(defs map[string]*LinkDef) {
+	switch b.Kind {
+	case Para:
+		text := strings.Join(b.Lines, "")
+		links := findLinks(text, defs)
+
+		//spans of the whole text, which may cross lines.
+		var spans []Span
+		last := 0
+		b.Links = nil
+		for _, lp := range links {
+			b.Links = append(b.Links, lp.link)
+			spans = textSpans(spans, text[last:lp.start], last)
+			spans = append(spans, Span{Kind: LinkSpan, Start: lp.start, End: lp.end, URL: lp.link.URL, Doc: lp.link.Doc})
+			last = lp.end
+		}
+		spans = textSpans(spans, text[last:], last)
+
+		b.Spans = make([][]Span, len(b.Lines))
+		start := 0
+		for i, line := range b.Lines {
+			end := start + len(strings.TrimSuffix(line, "\n"))
+			b.Spans[i] = clipSpans(text, spans, start, end)
+			start += len(line)
+		}
+
+	case List:
+		for i := range b.Items {
+			for j := range b.Items[i].Blocks {
+				b.Items[i].Blocks[j].inline(defs)
+			}
+		}
+	}
+}
+
+//clipSpans returns the parts of spans in text[start:end],
+//with offsets relative to start.
+func clipSpans(text string, spans []Span, start, end int) (out []Span) {
+	for _, s := range spans {
+		if s.End <= start || s.Start >= end {
+			continue
+		}
+		if s.Start < start {
+			s.Start = start
+		}
+		if s.End > end {
+			s.End = end
+		}
+		if s.Kind == LinkSpan {
+			//the text of a link is within its brackets.
+			lo, hi := s.Start, s.End
+			if text[lo] == '[' {
+				lo++
+			}
+			if hi > lo && text[hi-1] == ']' {
+				hi--
+			}
+			s.Text = text[lo:hi]
+		} else {
+			s.Text = text[s.Start:s.End]
+		}
+		s.Start -= start
+		s.End -= start
+		out = append(out, s)
+	}
+	return
+}
+
+//MarkIdents splits the plain text of every Para, including those in
+//lists, to mark the identifiers matched by m as IdentSpans.
+//
+//An identifier may be qualified, as in io.Reader or Package.Parse.
+//If a qualified identifier does not match, its parts are tried
+//individually.
+func (d *Doc) MarkIdents(m StringMatcher) {
+	for i := range d.Blocks {
+		d.Blocks[i].markIdents(m)
+	}
+}
+
+func (b *Block) markIdents(m StringMatcher) {
+	for i := range b.Items {
+		for j := range b.Items[i].Blocks {
+			b.Items[i].Blocks[j].markIdents(m)
+		}
+	}
+	for i, spans := range b.Spans {
+		var out []Span
+		for _, s := range spans {
+			if s.Kind != PlainSpan {
+				out = append(out, s)
+				continue
+			}
+			out = identSpans(out, s, m)
+		}
+		b.Spans[i] = out
+	}
+}
+
+//identSpans appends s, split into plain text and the identifiers matching m.
+func identSpans(out []Span, s Span, m StringMatcher) []Span {
+	text := s.Text
+	wrote := 0
+	mark := func(i, j int) {
+		if wrote < i {
+			out = append(out, Span{Kind: PlainSpan, Start: s.Start + wrote, End: s.Start + i, Text: text[wrote:i]})
+		}
+		out = append(out, Span{Kind: IdentSpan, Start: s.Start + i, End: s.Start + j, Text: text[i:j]})
+		wrote = j
+	}
+	for i := 0; i < len(text); {
+		id, ok := identPrefix(text[i:])
+		if !ok {
+			i++
+			continue
+		}
+		//extend to a qualified identifier, recording where each part ends.
+		ends := []int{i + len(id)}
+		for j := ends[0]; j < len(text) && text[j] == '.'; {
+			next, ok := identPrefix(text[j+1:])
+			if !ok {
+				break
+			}
+			j += 1 + len(next)
+			ends = append(ends, j)
+		}
+		end := ends[len(ends)-1]
+		if m.MatchString(text[i:end]) {
+			mark(i, end)
+		} else {
+			for k, start := 0, i; k < len(ends); k++ {
+				if m.MatchString(text[start:ends[k]]) {
+					mark(start, ends[k])
+				}
+				start = ends[k] + 1
+			}
+		}
+		i = end
+	}
+	if wrote < len(text) {
+		out = append(out, Span{Kind: PlainSpan, Start: s.Start + wrote, End: s.End, Text: text[wrote:]})
+	}
+	return out
+}
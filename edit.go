@@ -0,0 +1,282 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+//an edit replaces the bytes [start, end) of a file with text.
+type edit struct {
+	start, end int
+	text       []byte
+}
+
+type byStart []edit
+
+func (b byStart) Len() int      { return len(b) }
+func (b byStart) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byStart) Less(i, j int) bool {
+	//an insertion comes before anything removed at the same offset
+	if b[i].start == b[j].start {
+		return b[i].start == b[i].end && b[j].start != b[j].end
+	}
+	return b[i].start < b[j].start
+}
+
+//An Editor collects changes to the top level declarations of the files
+//of a Package and applies them to the source of the files, so that
+//everything not explicitly changed, including comments, is preserved.
+//The changed files are formatted with go/format, unless they were not
+//formatted to begin with, in which case they are only checked to parse.
+//
+//The Package must have been parsed with comments before creating an Editor.
+//Editing does not change the Package, and, after the files are written,
+//the Package no longer reflects them.
+type Editor struct {
+	p *Package
+	//filename → edits in the order they were made
+	edits map[string][]edit
+	//filename → the Package it belongs to, as a rename may edit
+	//files in more than one Package.
+	pkgs map[string]*Package
+}
+
+//Edit returns a new Editor for the files of p.
+func (p *Package) Edit() *Editor {
+	return &Editor{
+		p:     p,
+		edits: map[string][]edit{},
+		pkgs:  map[string]*Package{},
+	}
+}
+
+func (e *Editor) add(file string, start, end int, text []byte) {
+	e.addIn(e.p, file, start, end, text)
+}
+
+//addIn adds an edit to a file of p, which need not be the Editor's Package.
+func (e *Editor) addIn(p *Package, file string, start, end int, text []byte) {
+	e.edits[file] = append(e.edits[file], edit{start, end, text})
+	e.pkgs[file] = p
+}
+
+//extent returns the range of d, including its doc comment, and, if they
+//are otherwise alone on their lines, the indentation before it and the
+//line comment and newline after it.
+func (e *Editor) extent(d ast.Decl) (file string, start, end int, err error) {
+	pos := e.p.Position(d)
+	src, err := e.p.file(pos.Start.Filename)
+	if err != nil {
+		return
+	}
+	file, start, end = pos.Start.Filename, pos.Doc.Offset, pos.End.Offset
+
+	s := start
+	for s > 0 && (src[s-1] == ' ' || src[s-1] == '\t') {
+		s--
+	}
+	if s > 0 && src[s-1] != '\n' {
+		//not alone on the line
+		return
+	}
+
+	rest := src[end:]
+	if nl := bytes.IndexByte(rest, '\n'); nl >= 0 {
+		rest = rest[:nl+1]
+	}
+	trimmed := bytes.TrimSpace(rest)
+	if len(trimmed) == 0 || bytes.HasPrefix(trimmed, []byte("//")) {
+		start, end = s, end+len(rest)
+	}
+	return
+}
+
+//errShared returns the error of removing or replacing a declaration
+//whose spec is shared, as described by DeclPosition.Shared.
+func errShared(d ast.Decl) error {
+	return fmt.Errorf("%s shares its spec with other names", Idents(d)[0].Name)
+}
+
+//Remove deletes d, along with its doc and line comments.
+//
+//It is an error to remove one of several names split from a single spec
+//by SplitSpecs, as described by DeclPosition.Shared.
+func (e *Editor) Remove(d ast.Decl) error {
+	if e.p.Position(d).Shared {
+		return errShared(d)
+	}
+	file, start, end, err := e.extent(d)
+	if err != nil {
+		return err
+	}
+	e.add(file, start, end, nil)
+	return nil
+}
+
+//Replace replaces d, including its doc comment, with src.
+//If src does not include a doc comment, the replacement has none.
+//
+//As with Remove, d may not share its spec with other names.
+func (e *Editor) Replace(d ast.Decl, src []byte) error {
+	pos := e.p.Position(d)
+	if pos.Shared {
+		return errShared(d)
+	}
+	e.add(pos.Start.Filename, pos.Doc.Offset, pos.End.Offset, src)
+	return nil
+}
+
+//InsertBefore inserts src before d and its doc comment.
+//
+//The insertion is made at the start of the range removed by Remove,
+//so d may also be removed or replaced, as when replacing d with
+//a new declaration and a preamble.
+func (e *Editor) InsertBefore(d ast.Decl, src []byte) error {
+	file, start, _, err := e.extent(d)
+	if err != nil {
+		return err
+	}
+	e.add(file, start, start, append(append([]byte{}, src...), "\n\n"...))
+	return nil
+}
+
+//InsertAfter inserts src after d and its line comment.
+//
+//The insertion is made at the end of the range removed by Remove,
+//so d may also be removed or replaced.
+func (e *Editor) InsertAfter(d ast.Decl, src []byte) error {
+	file, _, end, err := e.extent(d)
+	if err != nil {
+		return err
+	}
+	text := append([]byte("\n\n"), src...)
+	if end > 0 {
+		if old, _ := e.p.file(file); old[end-1] == '\n' {
+			//the extent includes the newline ending d's line.
+			text = append(append([]byte("\n"), src...), '\n')
+		}
+	}
+	e.add(file, end, end, text)
+	return nil
+}
+
+//filename returns the absolute name of a file in the package.
+func (e *Editor) filename(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(e.p.Build.Dir, file)
+}
+
+//Append adds src to the end of file, which may be given
+//relative to the package directory.
+func (e *Editor) Append(file string, src []byte) error {
+	file = e.filename(file)
+	old, err := e.p.file(file)
+	if err != nil {
+		return err
+	}
+	e.add(file, len(old), len(old), append([]byte("\n"), src...))
+	return nil
+}
+
+//apply returns the edited and formatted contents of file.
+func (e *Editor) apply(file string) ([]byte, error) {
+	src, err := e.pkgs[file].file(file)
+	if err != nil {
+		return nil, err
+	}
+
+	es := append([]edit(nil), e.edits[file]...)
+	sort.Stable(byStart(es))
+
+	var b bytes.Buffer
+	last := 0
+	for _, ed := range es {
+		if ed.start < last {
+			return nil, fmt.Errorf("Overlapping edits in %s", file)
+		}
+		b.Write(src[last:ed.start])
+		b.Write(ed.text)
+		last = ed.end
+	}
+	b.Write(src[last:])
+
+	//formatting a file that was not already formatted would
+	//change far more than was edited.
+	if orig, err := format.Source(src); err != nil || !bytes.Equal(orig, src) {
+		if _, err := parser.ParseFile(token.NewFileSet(), file, b.Bytes(), parser.ParseComments); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	return out, nil
+}
+
+//Files returns the new contents of every file that has been edited,
+//by absolute filename.
+//
+//If the edits overlap, or if an edited file does not parse, an error is
+//returned, as the edits cannot be applied.
+func (e *Editor) Files() (map[string][]byte, error) {
+	out := map[string][]byte{}
+	for file := range e.edits {
+		src, err := e.apply(file)
+		if err != nil {
+			return nil, err
+		}
+		out[file] = src
+	}
+	return out, nil
+}
+
+//Write writes every edited file back to disk.
+//
+//No files are written unless the edits to every file can be applied.
+func (e *Editor) Write() error {
+	files, err := e.Files()
+	if err != nil {
+		return err
+	}
+	for file, src := range files {
+		if err = ioutil.WriteFile(file, src, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Diff returns a unified diff of the changes to every edited file,
+//in order of filename, without writing any files.
+func (e *Editor) Diff() ([]byte, error) {
+	files, err := e.Files()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, file := range names {
+		old, err := e.pkgs[file].file(file)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(Diff(file, file, old, files[file]))
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,165 @@
+package goutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const editSrc = `package a
+
+// A is removed.
+var A = 1 //A's comment
+
+// B is replaced.
+func B() {}
+
+// C stays.
+type C int
+`
+
+const editOut = `package a
+
+// B was replaced.
+func B() int { return 0 }
+
+// D is new.
+const D = 2
+
+// C stays.
+type C int
+
+func E() {}
+`
+
+func TestEdit(t *testing.T) {
+	p := importFile(t, editSrc)
+	ds := p.Decls()
+	e := p.Edit()
+	if err := e.Remove(ds[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Replace(ds[1], []byte("// B was replaced.\nfunc B() int { return 0 }")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.InsertBefore(ds[2], []byte("// D is new.\nconst D = 2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Append("a.go", []byte("func E() {}")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := e.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	for _, src := range files {
+		if string(src) != editOut {
+			t.Errorf("got\n%s\nexpected\n%s", src, editOut)
+		}
+	}
+
+	diff, err := e.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"-var A = 1 //A's comment\n", "+const D = 2\n", "+func E() {}\n", "@@ -1,"} {
+		if !strings.Contains(string(diff), line) {
+			t.Errorf("diff missing %q:\n%s", line, diff)
+		}
+	}
+}
+
+func TestEditOverlap(t *testing.T) {
+	p := importFile(t, editSrc)
+	ds := p.Decls()
+	e := p.Edit()
+	e.Remove(ds[1])
+	e.Replace(ds[1], []byte("func B() {}"))
+	if _, err := e.Files(); err == nil {
+		t.Error("expected overlapping edits to fail")
+	}
+}
+
+func TestEditShared(t *testing.T) {
+	p := importFile(t, positionSrc)
+	d := p.Decls().SplitSpecs().Named(Exact("D"))[0]
+	e := p.Edit()
+	if err := e.Remove(d); err == nil {
+		t.Error("expected removing D from var C, D to fail")
+	}
+	if err := e.Replace(d, []byte("var D = 3")); err == nil {
+		t.Error("expected replacing D in var C, D to fail")
+	}
+}
+
+func TestEditReplaceWithPreamble(t *testing.T) {
+	table := []struct {
+		src, target         string
+		before, with, after string
+		out                 string
+	}{
+		{
+			editSrc, "B",
+			"// D is new.\nconst D = 2", "", "func F() {}",
+			`package a
+
+// A is removed.
+var A = 1 //A's comment
+
+// D is new.
+const D = 2
+
+func F() {}
+
+// C stays.
+type C int
+`,
+		},
+		{
+			"package a\n\nvar (\n\t// A is replaced.\n\tA = 1 //A's comment\n\tB = 2\n)\n", "A",
+			"// D is new.\nD = 2", "A = 3", "F = 4",
+			`package a
+
+var (
+	// D is new.
+	D = 2
+
+	A = 3 //A's comment
+
+	F = 4
+	B = 2
+)
+`,
+		},
+	}
+	for _, c := range table {
+		p := importFile(t, c.src)
+		d := p.Decls().SplitSpecs().Named(Exact(c.target))[0]
+		e := p.Edit()
+		if err := e.InsertBefore(d, []byte(c.before)); err != nil {
+			t.Fatal(err)
+		}
+		if c.with == "" {
+			if err := e.Remove(d); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := e.Replace(d, []byte(c.with)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.InsertAfter(d, []byte(c.after)); err != nil {
+			t.Fatal(err)
+		}
+		files, err := e.Files()
+		if err != nil {
+			t.Fatalf("%s: %s", c.target, err)
+		}
+		for _, src := range files {
+			if string(src) != c.out {
+				t.Errorf("%s: got\n%s\nexpected\n%s", c.target, src, c.out)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package goutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//docInputFiles returns the files of pkg, sorted by name, followed by the test
+//files of p, parsed into fs, for go/doc to find examples in.
+//A test file that does not parse is skipped, as it has no examples
+//that could be run.
+func (p *Package) docInputFiles(pkg *ast.Package, fs *token.FileSet) (files []*ast.File) {
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+	for _, list := range [][]string{p.Build.TestGoFiles, p.Build.XTestGoFiles} {
+		for _, name := range list {
+			f, err := parser.ParseFile(fs, filepath.Join(p.Build.Dir, name), nil, parser.ParseComments)
+			if err == nil {
+				files = append(files, f)
+			}
+		}
+	}
+	return
+}
+
+type byExampleName []*doc.Example
+
+func (b byExampleName) Len() int           { return len(b) }
+func (b byExampleName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byExampleName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+//Examples returns the examples of p, sorted by name.
+//
+//The examples are also attached to what they document in p.Doc: those of
+//the package to p.Doc.Examples, and those of each function, type, and
+//method to its own Examples. The name of an example is the name of its
+//function without the Example prefix, so ExampleT_M_second is named
+//T_M_second, and the example of the package is named "".
+//An example named for no exported declaration is not included.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) Examples() (exs []*doc.Example) {
+	if p.Doc == nil {
+		return nil
+	}
+	exs = append(exs, p.Doc.Examples...)
+	for _, f := range p.Doc.Funcs {
+		exs = append(exs, f.Examples...)
+	}
+	for _, t := range p.Doc.Types {
+		exs = append(exs, t.Examples...)
+		for _, f := range t.Funcs {
+			exs = append(exs, f.Examples...)
+		}
+		for _, m := range t.Methods {
+			exs = append(exs, m.Examples...)
+		}
+	}
+	sort.Sort(byExampleName(exs))
+	return
+}
+
+//An ExampleResult is the outcome of running an example with RunExamples.
+type ExampleResult struct {
+	Example *doc.Example
+	//Output is what the example wrote to its standard output.
+	Output string
+	//Err is set if the example could not be run, or did not exit
+	//successfully. It includes anything written to standard error.
+	Err error
+}
+
+//Passed reports whether the example ran and its output was that
+//of its output comment.
+//
+//The output is compared as go test compares it: ignoring leading and
+//trailing space, and the order of the lines if the comment
+//is "Unordered output:".
+func (r ExampleResult) Passed() bool {
+	if r.Err != nil {
+		return false
+	}
+	got, want := strings.TrimSpace(r.Output), strings.TrimSpace(r.Example.Output)
+	if r.Example.Unordered {
+		return sortLines(got) == sortLines(want)
+	}
+	return got == want
+}
+
+func sortLines(s string) string {
+	lines := strings.Split(s, "\n")
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+//String reports the result in the manner of go test -v:
+//"ok" and the name of the example's function if it passed and, if not,
+//"FAIL" and the name followed by the error or the output that
+//was expected and the output that was not.
+func (r ExampleResult) String() string {
+	name := "Example" + r.Example.Name
+	switch {
+	case r.Passed():
+		return "ok " + name
+	case r.Err != nil:
+		return fmt.Sprintf("FAIL %s: %s", name, r.Err)
+	}
+	return fmt.Sprintf("FAIL %s\ngot:\n%s\nwant:\n%s", name, strings.TrimSpace(r.Output), strings.TrimSpace(r.Example.Output))
+}
+
+//RunExamples runs the examples of p whose names, as given by Examples,
+//are matched by m, or every example if m is nil, and returns their results.
+//
+//As with go test, only an example with an output comment is run,
+//and its output is checked by ExampleResult.Passed.
+//Unlike go test, each example is run on its own, in a Gostrap,
+//with go run and the build tags of p.Context, so neither the tests
+//of p nor any other examples are run.
+//
+//The example is run in GOPATH mode, with GO111MODULE=off, so p, and
+//every package it imports, must be in a directory of GOPATH.
+//
+//The program run is the one go/doc creates for an example in
+//an external test package, such as package p_test, that imports p.
+//There is no such program for an example in the package itself,
+//or one that refers to declarations in the test files of p that are not
+//in its own file; the result of such an example has Err set.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) RunExamples(m StringMatcher) ([]ExampleResult, error) {
+	if p.Doc == nil {
+		return nil, errors.New("Package documentation has not been parsed")
+	}
+	var rs []ExampleResult
+	for _, ex := range p.Examples() {
+		if ex.Output == "" && !ex.EmptyOutput {
+			continue
+		}
+		if m != nil && !m.MatchString(ex.Name) {
+			continue
+		}
+		r := ExampleResult{Example: ex}
+		if ex.Play == nil {
+			r.Err = fmt.Errorf("Example%s cannot be run outside of package %s", ex.Name, p.Build.Name)
+			rs = append(rs, r)
+			continue
+		}
+		var src bytes.Buffer
+		if err := format.Node(&src, p.docFileSet, ex.Play); err != nil {
+			return nil, err
+		}
+		r.Output, r.Err = p.runExample(src.Bytes())
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+//runExample runs the program src in a Gostrap and returns its output.
+func (p *Package) runExample(src []byte) (out string, err error) {
+	var stdout, stderr bytes.Buffer
+	err = WithGostrap(func(t *Gostrap) error {
+		if err := t.AddFile("main.go", src); err != nil {
+			return err
+		}
+		//the Gostrap is a GOPATH workspace, whatever the environment says.
+		t.Env = append(t.Env, "GO111MODULE=off")
+		t.Args = append(t.Args, "run")
+		t.Args = append(t.Args, tagargs(p.Context.BuildTags)...)
+		t.Args = append(t.Args, "main.go")
+		t.Stdout, t.Stderr = &stdout, &stderr
+		return t.Run()
+	})
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		err = fmt.Errorf("%s\n%s", err, msg)
+	}
+	return stdout.String(), err
+}
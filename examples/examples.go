@@ -0,0 +1,98 @@
+//Examples runs the examples of a Go package, or set of Go packages,
+//with the standard build tags, and reports those whose output is not
+//that of their output comment.
+//
+//The packages may be specified as with the go(1) tool, including the
+//special ... operator.
+//
+//Each example is run on its own, so the tests of the packages are not.
+//Only examples with an output comment are run, and only those
+//in an external test package can be run; how is documented in
+//goutil.Package.RunExamples.
+//The examples are run in GOPATH mode, whatever GO111MODULE is set to,
+//so the packages must be in a GOPATH workspace.
+//
+//With -l, the examples are listed, by the name of their function,
+//instead of being run. With -v, every example run is reported, not just
+//those that fail.
+//
+//Examples exits with status 1 if any example fails.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	list    = flag.Bool("l", false, "list the examples instead of running them")
+	run     = flag.String("run", "", "only examples whose names, less the Example prefix, match `regexp`")
+	verbose = flag.Bool("v", false, "report the examples that pass as well")
+	tags    = gocli.TagsFlag("")
+)
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	var m goutil.StringMatcher
+	if *run != "" {
+		re, err := regexp.Compile(*run)
+		if err != nil {
+			fatal(err)
+		}
+		m = re
+	}
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+
+	failed := false
+	for _, p := range gocli.Flatten(pss) {
+		if err := p.ParseDocs(0); err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		if *list {
+			for _, ex := range p.Examples() {
+				if m == nil || m.MatchString(ex.Name) {
+					fmt.Printf("%s Example%s\n", p.Build.ImportPath, ex.Name)
+				}
+			}
+			continue
+		}
+		rs, err := p.RunExamples(m)
+		if err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		for _, r := range rs {
+			if !r.Passed() {
+				failed = true
+			} else if !*verbose {
+				continue
+			}
+			fmt.Println(p.Build.ImportPath+":", r)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,119 @@
+package goutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var exampleFiles = map[string]string{
+	"ex.go": `//Package ex greets.
+package ex
+
+//Hello returns a greeting.
+func Hello() string {
+	return "hello"
+}
+
+//T is a type.
+type T struct{}
+
+//M returns a farewell.
+func (T) M() string {
+	return "bye"
+}
+`,
+	"ex_test.go": `package ex_test
+
+import (
+	"fmt"
+
+	"ex"
+)
+
+func Example() {
+	fmt.Println(ex.Hello())
+	fmt.Println(ex.T{}.M())
+	//Unordered output:
+	//bye
+	//hello
+}
+
+func ExampleHello() {
+	fmt.Println(ex.Hello())
+	//Output: hello
+}
+
+func ExampleHello_wrong() {
+	fmt.Println(ex.Hello())
+	//Output: goodbye
+}
+
+func ExampleT_M() {
+	fmt.Println(ex.T{}.M())
+}
+`,
+}
+
+func TestExamples(t *testing.T) {
+	root, err := ioutil.TempDir("", "goutil-examples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "src", "ex")
+	if err = os.MkdirAll(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range exampleFiles {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//the examples are run with go run, which must find the package,
+	//in GOPATH mode whatever the environment says.
+	t.Setenv("GOPATH", root)
+	t.Setenv("GO111MODULE", "on")
+
+	p, err := ImportDir(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, ex := range p.Examples() {
+		names = append(names, ex.Name)
+	}
+	if got, want := strings.Join(names, " "), " Hello Hello_wrong T_M"; got != want {
+		t.Errorf("expected examples %q, got %q", want, got)
+	}
+	if len(p.Doc.Types) != 1 || len(p.Doc.Types[0].Methods) != 1 || len(p.Doc.Types[0].Methods[0].Examples) != 1 {
+		t.Error("expected ExampleT_M to be attached to T.M")
+	}
+
+	rs, err := p.RunExamples(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]bool{
+		"":            true,
+		"Hello":       true,
+		"Hello_wrong": false,
+	}
+	if len(rs) != len(expected) {
+		t.Fatalf("expected %d results, got %v", len(expected), rs)
+	}
+	for _, r := range rs {
+		if r.Passed() != expected[r.Example.Name] {
+			t.Errorf("unexpected result %s", r)
+		}
+	}
+	if got := rs[2].String(); got != "FAIL ExampleHello_wrong\ngot:\nhello\nwant:\ngoodbye" {
+		t.Errorf("unexpected report %q", got)
+	}
+}
@@ -0,0 +1,243 @@
+package goutil
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//A Flag is a command line flag defined with the flag package.
+type Flag struct {
+	Name string
+	//Usage is the usage message of the flag, without the back quotes
+	//around the name of its argument, if it had any.
+	Usage string
+	//Arg is the name of the flag's argument, as printed by
+	//flag.PrintDefaults, or "" for a boolean flag.
+	Arg string
+	//Default is the default value of the flag, or "" if it is not known.
+	//A string is quoted.
+	Default string
+	//Pos is where the flag is defined.
+	Pos token.Position
+}
+
+//Flags returns the command line flags defined by p, in the order
+//they are defined.
+//
+//A flag is found where it is defined by a function of the flag package,
+//or a method of flag.FlagSet, with a constant name and usage. Calls to
+//functions of other packages that import flag, such as gocli.TagsFlag,
+//are followed, so flags defined on behalf of p are found too.
+//Whether the code defining a flag is ever run is not considered.
+//
+//Flags calls TypeCheck.
+func (p *Package) Flags() ([]Flag, error) {
+	if err := p.TypeCheck(); p.Types == nil {
+		return nil, err
+	}
+	s := &flagScan{
+		top:   p,
+		names: map[string]bool{},
+		seen:  map[*types.Func]bool{},
+	}
+	for _, f := range p.astFiles() {
+		s.scan(p, f)
+	}
+	return s.flags, nil
+}
+
+//A flagScan is the state of a single call to Flags.
+type flagScan struct {
+	top   *Package
+	flags []Flag
+	names map[string]bool
+	//the functions followed.
+	seen map[*types.Func]bool
+}
+
+//calledFunc returns the function or method called by call, if it is known.
+func calledFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	fun := call.Fun
+	for {
+		p, ok := fun.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		fun = p.X
+	}
+	var id *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		id = f
+	case *ast.SelectorExpr:
+		id = f.Sel
+	case *ast.IndexExpr:
+		//an instantiated generic function.
+		if x, ok := f.X.(*ast.Ident); ok {
+			id = x
+		}
+	}
+	if id == nil {
+		return nil
+	}
+	f, _ := info.Uses[id].(*types.Func)
+	if f == nil {
+		return nil
+	}
+	return f.Origin()
+}
+
+//importsFlag reports whether tp imports the flag package.
+func importsFlag(tp *types.Package) bool {
+	for _, imp := range tp.Imports() {
+		if imp.Path() == "flag" {
+			return true
+		}
+	}
+	return false
+}
+
+//scan records the flags defined in n, which is from p.
+func (s *flagScan) scan(p *Package, n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		f := calledFunc(p.Info, call)
+		if f == nil || f.Pkg() == nil {
+			return true
+		}
+		switch {
+		case f.Pkg().Path() == "flag":
+			s.define(p, call, f)
+		case f.Pkg() != s.top.Types && !s.seen[f] && importsFlag(f.Pkg()):
+			s.seen[f] = true
+			s.follow(p, f)
+		}
+		return true
+	})
+}
+
+//follow scans the body of f, which is called from p.
+func (s *flagScan) follow(p *Package, f *types.Func) {
+	//import the package as the type checker did, to share its objects.
+	bp, err := p.Context.Import(f.Pkg().Path(), p.Build.Dir, build.FindOnly)
+	if err != nil {
+		return
+	}
+	dp, err := Import(p.Context, bp.Dir)
+	if err != nil || dp.Types != f.Pkg() || dp.Info == nil {
+		return
+	}
+	for _, file := range dp.astFiles() {
+		for _, d := range file.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if ok && fd.Body != nil && dp.Info.Defs[fd.Name] == f {
+				s.scan(dp, fd.Body)
+				return
+			}
+		}
+	}
+}
+
+//define records the flag defined by call, a call of f from the flag package,
+//if f defines a flag.
+func (s *flagScan) define(p *Package, call *ast.CallExpr, f *types.Func) {
+	params := f.Type().(*types.Signature).Params()
+	name, usage, value := -1, -1, -1
+	for i := 0; i < params.Len(); i++ {
+		switch params.At(i).Name() {
+		case "name":
+			name = i
+		case "usage":
+			usage = i
+		case "value":
+			value = i
+		}
+	}
+	if name < 0 || usage < 0 || len(call.Args) != params.Len() {
+		return
+	}
+
+	fl := Flag{Pos: p.FileSet.Position(call.Pos())}
+	var ok bool
+	if fl.Name, ok = constString(p.Info, call.Args[name]); !ok || s.names[fl.Name] {
+		return
+	}
+	if fl.Usage, ok = constString(p.Info, call.Args[usage]); !ok {
+		return
+	}
+	s.names[fl.Name] = true
+	fl.Arg, fl.Usage = unquoteUsage(f.Name(), fl.Usage)
+	//the value of Var is the flag.Value itself.
+	if value >= 0 && !types.IsInterface(params.At(value).Type()) {
+		fl.Default = s.defaultOf(p, call.Args[value])
+	}
+	s.flags = append(s.flags, fl)
+}
+
+//constString returns the value of x if it is a constant string.
+func constString(info *types.Info, x ast.Expr) (string, bool) {
+	v := info.Types[x].Value
+	if v == nil || v.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(v), true
+}
+
+//defaultOf returns the default value x of a flag defined in p.
+func (s *flagScan) defaultOf(p *Package, x ast.Expr) string {
+	tv := p.Info.Types[x]
+	if v := tv.Value; v != nil {
+		if n, ok := tv.Type.(*types.Named); ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == "time" && n.Obj().Name() == "Duration" {
+			if d, ok := constant.Int64Val(v); ok {
+				return time.Duration(d).String()
+			}
+		}
+		if v.Kind() == constant.String {
+			return strconv.Quote(constant.StringVal(v))
+		}
+		return v.String()
+	}
+	//outside p, the value is likely a parameter of the function followed.
+	if p != s.top {
+		return ""
+	}
+	var b bytes.Buffer
+	printer.Fprint(&b, p.FileSet, x)
+	return b.String()
+}
+
+//unquoteUsage is flag.UnquoteUsage for a flag defined by the function fn.
+func unquoteUsage(fn, usage string) (arg, u string) {
+	if i := strings.IndexByte(usage, '`'); i >= 0 {
+		if j := strings.IndexByte(usage[i+1:], '`'); j >= 0 {
+			j += i + 1
+			return usage[i+1 : j], usage[:i] + usage[i+1:j] + usage[j+1:]
+		}
+	}
+	switch strings.TrimSuffix(fn, "Var") {
+	case "Bool", "BoolFunc":
+		return "", usage
+	case "Duration":
+		return "duration", usage
+	case "Float64":
+		return "float", usage
+	case "Int", "Int64":
+		return "int", usage
+	case "String":
+		return "string", usage
+	case "Uint", "Uint64":
+		return "uint", usage
+	}
+	return "value", usage
+}
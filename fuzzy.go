@@ -0,0 +1,166 @@
+package goutil
+
+import (
+	"sort"
+	"unicode"
+)
+
+//A Scorer is a StringMatcher that can rank the strings it matches.
+type Scorer interface {
+	StringMatcher
+	//Score returns a positive number, larger for better matches,
+	//if the string matches, and 0 otherwise.
+	Score(string) int
+}
+
+//FuzzyMatcher matches strings containing its runes, in order, ignoring case,
+//such as an abbreviation of a camel case name. For example,
+//	FuzzyMatcher("NGS")
+//matches both NewGostrap and NewGoStruct, but scores NewGoStruct higher,
+//as each rune matched starts a word.
+type FuzzyMatcher string
+
+//MatchString matches strings with a positive Score.
+func (f FuzzyMatcher) MatchString(s string) bool {
+	return f.Score(s) > 0
+}
+
+const (
+	fuzzyMatch       = 1  //any matched rune
+	fuzzyCase        = 1  //matched rune has the same case
+	fuzzyConsecutive = 4  //matched rune follows the last matched rune
+	fuzzyHump        = 8  //matched rune starts a word
+	fuzzyFirst       = 16 //matched rune is the first in the string
+)
+
+//hump reports whether rs[i] starts a word, in camel case or snake case.
+func hump(rs []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, prev := rs[i], rs[i-1]
+	switch {
+	case prev == '_':
+		return r != '_'
+	case unicode.IsUpper(r):
+		//the last capital of an acronym in HTTPServer starts Server
+		return !unicode.IsUpper(prev) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))
+	case unicode.IsDigit(r):
+		return !unicode.IsDigit(prev)
+	}
+	return false
+}
+
+//Score returns how well s matches.
+//
+//Every matched rune scores, with bonuses for matching the case, for
+//consecutive runes, and especially for runes that start a word.
+//The best possible score is found and then lowered slightly
+//by the length of s, so that shorter names rank higher.
+func (f FuzzyMatcher) Score(s string) int {
+	p, rs := []rune(string(f)), []rune(s)
+	if len(p) == 0 {
+		return 1
+	}
+	if len(p) > len(rs) {
+		return 0
+	}
+
+	//best[j] is the best score with the current rune of p matched at rs[j],
+	//or -1 if impossible.
+	best := make([]int, len(rs))
+	prev := make([]int, len(rs))
+
+	for i, pr := range p {
+		//max of prev[:j-1], the best score of a nonconsecutive match
+		gapmax := -1
+		for j, r := range rs {
+			best[j] = -1
+			if j >= 2 && prev[j-2] > gapmax {
+				gapmax = prev[j-2]
+			}
+			if unicode.ToLower(r) != unicode.ToLower(pr) {
+				continue
+			}
+
+			before := -1
+			switch {
+			case i == 0:
+				before = 0
+			case j > 0 && prev[j-1] >= 0:
+				before = prev[j-1] + fuzzyConsecutive
+			}
+			if i > 0 && gapmax > before {
+				before = gapmax
+			}
+			if before < 0 {
+				continue
+			}
+
+			score := before + fuzzyMatch
+			if r == pr {
+				score += fuzzyCase
+			}
+			if hump(rs, j) {
+				score += fuzzyHump
+			}
+			if j == 0 {
+				score += fuzzyFirst
+			}
+			best[j] = score
+		}
+		best, prev = prev, best
+	}
+
+	max := 0
+	for _, score := range prev {
+		if score > max {
+			max = score
+		}
+	}
+	if max == 0 {
+		return 0
+	}
+	if max -= len(rs) / 4; max < 1 {
+		max = 1
+	}
+	return max
+}
+
+//scored is a Decls with a score for each Decl.
+type scored struct {
+	ds     Decls
+	scores []int
+}
+
+func (s scored) Len() int { return len(s.ds) }
+func (s scored) Swap(i, j int) {
+	s.ds[i], s.ds[j] = s.ds[j], s.ds[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+func (s scored) Less(i, j int) bool {
+	return s.scores[i] > s.scores[j]
+}
+
+//Ranked returns all Decls whose name matches sc, as with Named,
+//ordered from best to worst score. Decls with the same score
+//remain in their original order.
+//
+//The score of a GenDecl with multiple names is the best score of its names.
+func (ds Decls) Ranked(sc Scorer) Decls {
+	var s scored
+	for _, d := range ds {
+		max := 0
+		for _, id := range Idents(d) {
+			if score := sc.Score(id.Name); score > max {
+				max = score
+			}
+		}
+		if max > 0 {
+			s.ds = append(s.ds, d)
+			s.scores = append(s.scores, max)
+		}
+	}
+	sort.Stable(s)
+	return s.ds
+}
@@ -0,0 +1,113 @@
+package goutil
+
+import (
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+//generatedHeader matches the line that marks a file as generated,
+//as described in https://golang.org/s/generatedcode.
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+//isGenerated reports whether f, parsed with comments, has the standard
+//header for generated code in a line comment before its package clause.
+func isGenerated(f *ast.File) bool {
+	for _, g := range f.Comments {
+		if g.Pos() > f.Package {
+			break
+		}
+		for _, c := range g.List {
+			if generatedHeader.MatchString(c.Text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//generatedFiles returns the set of files of pkg, parsed with comments,
+//that are generated.
+func generatedFiles(pkg *ast.Package) map[string]bool {
+	gen := map[string]bool{}
+	for name, f := range pkg.Files {
+		if isGenerated(f) {
+			gen[name] = true
+		}
+	}
+	return gen
+}
+
+//stripComments removes the comments from the files of pkg, leaving them
+//as if they had been parsed without comments.
+func stripComments(pkg *ast.Package) {
+	for _, f := range pkg.Files {
+		f.Comments = nil
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.File:
+				n.Doc = nil
+			case *ast.GenDecl:
+				n.Doc = nil
+			case *ast.FuncDecl:
+				n.Doc = nil
+			case *ast.Field:
+				n.Doc, n.Comment = nil, nil
+			case *ast.ImportSpec:
+				n.Doc, n.Comment = nil, nil
+			case *ast.ValueSpec:
+				n.Doc, n.Comment = nil, nil
+			case *ast.TypeSpec:
+				n.Doc, n.Comment = nil, nil
+			}
+			return true
+		})
+	}
+}
+
+//Generated reports whether the named file of p has the standard
+//	// Code generated ... DO NOT EDIT.
+//header of generated code before its package clause.
+//The file may be given by its name in Build.GoFiles or its complete path.
+//
+//It is up to the caller to call Parse before invoking this method.
+func (p *Package) Generated(file string) bool {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(p.Build.Dir, file)
+	}
+	return p.generated[file]
+}
+
+//GeneratedFiles returns the names of the generated files in Build.GoFiles,
+//sorted.
+//
+//It is up to the caller to call Parse before invoking this method.
+func (p *Package) GeneratedFiles() (files []string) {
+	for file := range p.generated {
+		files = append(files, filepath.Base(file))
+	}
+	sort.Strings(files)
+	return
+}
+
+func (ds Decls) generated(p *Package, want bool) (out Decls) {
+	for _, d := range ds {
+		if p.generated[p.FileSet.Position(d.Pos()).Filename] == want {
+			out = append(out, d)
+		}
+	}
+	return
+}
+
+//InGenerated returns the Decls, which must be from p, that are
+//in generated files.
+func (ds Decls) InGenerated(p *Package) Decls {
+	return ds.generated(p, true)
+}
+
+//NotGenerated returns the Decls, which must be from p, that are not
+//in generated files.
+func (ds Decls) NotGenerated(p *Package) Decls {
+	return ds.generated(p, false)
+}
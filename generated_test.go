@@ -0,0 +1,70 @@
+package goutil
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestIsGenerated(t *testing.T) {
+	table := []struct {
+		src string
+		gen bool
+	}{
+		{"// Code generated by stringer. DO NOT EDIT.\n\npackage a\n", true},
+		{"// +build linux\n\n// Code generated by hand. DO NOT EDIT.\npackage a\n", true},
+		{"/* license */\n// Code generated by hand. DO NOT EDIT.\npackage a\n", true},
+		{"// Code generated by hand. DO NOT EDIT\npackage a\n", false},
+		{"//Code generated by hand. DO NOT EDIT.\npackage a\n", false},
+		{"package a\n\n// Code generated by hand. DO NOT EDIT.\n", false},
+		{"/* Code generated by hand. DO NOT EDIT. */\npackage a\n", false},
+	}
+	for i, c := range table {
+		f, err := parser.ParseFile(token.NewFileSet(), "a.go", c.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g := isGenerated(f); g != c.gen {
+			t.Errorf("%d: expected %v, got %v for\n%s", i, c.gen, g, c.src)
+		}
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	files := map[string]string{
+		"a.go":     "//A is written.\npackage a\n\n//A is written.\nvar A int\n",
+		"a_gen.go": "// Code generated by hand. DO NOT EDIT.\n\npackage a\n\n//B is generated.\nvar B int\n",
+	}
+	for _, comments := range []bool{false, true} {
+		p := importFiles(t, files)
+		if !comments {
+			//importFiles parses with comments.
+			p.AST, p.FileSet, p.generated = nil, nil, nil
+			if err := p.Parse(false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := strings.Join(p.GeneratedFiles(), " "); got != "a_gen.go" {
+			t.Errorf("comments %v: expected a_gen.go to be generated, got %q", comments, got)
+		}
+		if !p.Generated("a_gen.go") || p.Generated("a.go") {
+			t.Errorf("comments %v: Generated disagrees with GeneratedFiles", comments)
+		}
+		ds := p.Decls()
+		if in := ds.InGenerated(p); len(in) != 1 || Idents(in[0])[0].Name != "B" {
+			t.Errorf("comments %v: expected B in generated files, got %d", comments, len(in))
+		}
+		if out := ds.NotGenerated(p); len(out) != 1 || Idents(out[0])[0].Name != "A" {
+			t.Errorf("comments %v: expected A in other files, got %d", comments, len(out))
+		}
+		for _, d := range ds {
+			if doc := DeclDoc(d); (doc != nil) != comments {
+				t.Errorf("comments %v: got doc %v", comments, doc)
+			}
+		}
+		if f := p.AST.Files[p.FileSet.File(ds[0].Pos()).Name()]; (len(f.Comments) > 0) != comments {
+			t.Errorf("comments %v: got comments %v", comments, f.Comments)
+		}
+	}
+}
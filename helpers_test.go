@@ -0,0 +1,60 @@
+package goutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//importSrc imports and parses src as the file a.go of a package, without
+//comments, removing the file once it is parsed.
+func importSrc(t *testing.T, src string) *Package {
+	dir, err := ioutil.TempDir("", "goutil-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ImportDir(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(false); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+	return p
+}
+
+//importFiles writes files, by name, to a temporary directory, which is kept
+//until the test ends for tests that read the files, and imports and
+//parses them with comments.
+func importFiles(t *testing.T, files map[string]string) *Package {
+	dir, err := ioutil.TempDir("", "goutil-edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	for name, src := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := ImportDir(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Parse(true); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+//importFile is importFiles for the single file a.go.
+func importFile(t *testing.T, src string) *Package {
+	return importFiles(t, map[string]string{"a.go": src})
+}
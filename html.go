@@ -0,0 +1,197 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//headingID returns the id of a Head block with the given text,
+//as generated by go/doc.
+func headingID(text string) string {
+	return "hdr-" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, text)
+}
+
+//htmlWriter is a docWriter for HTML.
+type htmlWriter struct {
+	bytes.Buffer
+	pkgURL func(string) string
+}
+
+func (h *htmlWriter) heading(level int, anchor, text string) {
+	if anchor != "" {
+		fmt.Fprintf(h, "<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(anchor), html.EscapeString(text), level)
+	} else {
+		fmt.Fprintf(h, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+	}
+}
+
+func (h *htmlWriter) decl(src string) {
+	h.pre(src)
+}
+
+func (h *htmlWriter) doc(d *Doc) {
+	h.blocks(d.Blocks)
+}
+
+func (h *htmlWriter) index(entries []indexEntry) {
+	h.WriteString("<ul>\n")
+	for i, e := range entries {
+		fmt.Fprintf(h, "<li><a href=\"#%s\">%s</a>", html.EscapeString(e.anchor), html.EscapeString(e.text))
+		//the entries of depth 1 are nested in the entry before them.
+		next := 0
+		if i+1 < len(entries) {
+			next = entries[i+1].depth
+		}
+		switch {
+		case next > e.depth:
+			h.WriteString("\n<ul>\n")
+		case next < e.depth:
+			h.WriteString("</li>\n</ul>\n</li>\n")
+		default:
+			h.WriteString("</li>\n")
+		}
+	}
+	h.WriteString("</ul>\n")
+}
+
+func (h *htmlWriter) pre(src string) {
+	if !strings.HasSuffix(src, "\n") {
+		src += "\n"
+	}
+	fmt.Fprintf(h, "<pre>%s</pre>\n", html.EscapeString(src))
+}
+
+func (h *htmlWriter) href(url string) string {
+	return html.EscapeString(url)
+}
+
+//text writes the lines of a Para.
+func (h *htmlWriter) text(b Block) {
+	for i, line := range b.Lines {
+		if i >= len(b.Spans) || b.Spans[i] == nil {
+			h.WriteString(html.EscapeString(line))
+			continue
+		}
+		for _, s := range b.Spans[i] {
+			switch s.Kind {
+			case URLSpan:
+				fmt.Fprintf(h, "<a href=\"%s\">%s</a>", h.href(s.URL), html.EscapeString(s.Text))
+			case IdentSpan:
+				url := "#" + s.Text
+				if s.Doc != nil {
+					url = docLinkURL(s.Doc, h.pkgURL)
+				}
+				fmt.Fprintf(h, "<a href=\"%s\">%s</a>", h.href(url), html.EscapeString(s.Text))
+			case LinkSpan:
+				url := s.URL
+				if s.Doc != nil {
+					url = docLinkURL(s.Doc, h.pkgURL)
+				}
+				fmt.Fprintf(h, "<a href=\"%s\">%s</a>", h.href(url), html.EscapeString(s.Text))
+			default:
+				h.WriteString(html.EscapeString(s.Text))
+			}
+		}
+		if strings.HasSuffix(line, "\n") {
+			h.WriteString("\n")
+		}
+	}
+}
+
+func (h *htmlWriter) blocks(bs []Block) {
+	for _, b := range bs {
+		switch b.Kind {
+		case Para:
+			h.WriteString("<p>")
+			h.text(b)
+			h.WriteString("</p>\n")
+		case Head:
+			fmt.Fprintf(h, "<h3 id=\"%s\">%s</h3>\n", html.EscapeString(headingID(b.Lines[0])), html.EscapeString(b.Lines[0]))
+		case Pre:
+			h.pre(strings.Join(b.Lines, ""))
+		case List:
+			tag := "ul"
+			if b.Items[0].Number != "" {
+				tag = "ol"
+			}
+			fmt.Fprintf(h, "<%s>\n", tag)
+			next := 1
+			for _, it := range b.Items {
+				h.WriteString("<li")
+				if it.Number != "" {
+					//the value is only needed when it is out of sequence.
+					if n, err := strconv.Atoi(it.Number); err != nil || n != next {
+						fmt.Fprintf(h, " value=\"%s\"", html.EscapeString(it.Number))
+						next = n
+					}
+					next++
+				}
+				h.WriteString(">")
+				for _, p := range it.Blocks {
+					if b.Spaced || p.Kind != Para {
+						h.blocks([]Block{p})
+					} else {
+						h.text(p)
+					}
+				}
+				h.WriteString("</li>\n")
+			}
+			fmt.Fprintf(h, "</%s>\n", tag)
+		}
+	}
+}
+
+//HTML writes blocks to w as HTML.
+//
+//Headings are given the same ids as go/doc gives them. Links to the
+//documentation of other packages go to pkg.go.dev, and links to
+//declarations in the same package, and the identifiers marked by
+//MarkIdents, go to anchors of the same name, as written by the HTML
+//method of Package.
+func HTML(w io.Writer, blocks []Block) error {
+	h := &htmlWriter{pkgURL: pkgGoDev}
+	h.blocks(blocks)
+	_, err := w.Write(h.Bytes())
+	return err
+}
+
+//HTML writes the documentation of p to w as a fragment of HTML, as godoc
+//would present it: the package overview, an index, and then the
+//documentation of each exported declaration.
+//
+//The index, the doc links in the documentation, such as [Package], and
+//any methods of the package in the documentation, such as Package.Parse,
+//are linked to their declarations, which are given ids of the same name.
+//Other unqualified identifiers are not linked.
+//
+//Qualified identifiers in the documentation, such as io.Reader, are linked
+//to their declarations in the package imported with that name by the file
+//of the documentation, which may have renamed it, if that package is one
+//of deps and its documentation has been parsed. Such an identifier in
+//brackets, such as [f.Println] where fmt is imported as f, is linked
+//likewise.
+//The URL of the documentation of a package is given by pkgURL, which is
+//passed the import path. If pkgURL is nil, its page on pkg.go.dev is used.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) HTML(w io.Writer, deps Packages, pkgURL func(importPath string) string) error {
+	if pkgURL == nil {
+		pkgURL = pkgGoDev
+	}
+	h := &htmlWriter{pkgURL: pkgURL}
+	if err := p.renderDoc(h, deps); err != nil {
+		return err
+	}
+	_, err := w.Write(h.Bytes())
+	return err
+}
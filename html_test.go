@@ -0,0 +1,124 @@
+package goutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	const text = `Package x compares a<b & c, see [io.Reader].
+
+Two Steps
+
+The steps are:
+ 2. this
+ 3. that
+
+Code:
+
+	x := "<y>"
+`
+	const expected = `<p>Package x compares a&lt;b &amp; c, see <a href="https://pkg.go.dev/io#Reader">io.Reader</a>.
+</p>
+<h3 id="hdr-Two_Steps">Two Steps</h3>
+<p>The steps are:
+</p>
+<ol>
+<li value="2">this
+</li>
+<li>that
+</li>
+</ol>
+<p>Code:
+</p>
+<pre>x := &#34;&lt;y&gt;&#34;
+</pre>
+`
+	var b bytes.Buffer
+	if err := HTML(&b, DocParse(text)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestPackageHTML(t *testing.T) {
+	p := importFiles(t, map[string]string{
+		"a.go": `//Package a prints with f.Println and [f.Println].
+package a
+
+import (
+	f "fmt"
+	"strings"
+)
+
+//Print calls f.Printf and strings.Builder.String, not fmt.Println or f.Nothing.
+func Print() { f.Println(strings.Repeat("x", 2)) }
+`,
+		"b.go": `package a
+
+import "fmt"
+
+//Other calls fmt.Println, not f.Println, and uses fmt.Stringer.
+func Other() { fmt.Println() }
+`,
+	})
+	if err := p.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+	var deps Packages
+	for _, path := range []string{"fmt", "strings"} {
+		dep, err := Import(nil, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = dep.ParseDocs(0); err != nil {
+			t.Fatal(err)
+		}
+		deps = append(deps, dep)
+	}
+	pkgURL := func(path string) string {
+		return "/pkg/" + path
+	}
+
+	var b bytes.Buffer
+	if err := p.HTML(&b, deps, pkgURL); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	expected := []string{
+		`<p>Package a prints with <a href="/pkg/fmt#Println">f.Println</a> and <a href="/pkg/fmt#Println">f.Println</a>.`,
+		`<p>Print calls <a href="/pkg/fmt#Printf">f.Printf</a> and <a href="/pkg/strings#Builder.String">strings.Builder.String</a>, not fmt.Println or f.Nothing.`,
+		`<p>Other calls <a href="/pkg/fmt#Println">fmt.Println</a>, not f.Println, and uses <a href="/pkg/fmt#Stringer">fmt.Stringer</a>.`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("expected\n%s\nin\n%s", e, got)
+		}
+	}
+
+	//nor is the first word of the package documentation taken for
+	//a declaration of the package.
+	b.Reset()
+	q := importFile(t, "//Package a is documented.\npackage a\n\n//Package is a type.\ntype Package int\n")
+	if err := q.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.HTML(&b, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); !strings.Contains(got, "<p>Package a is documented.\n</p>") {
+		t.Errorf("expected Package not to be linked in\n%s", got)
+	}
+
+	//without deps, nothing is linked to other packages.
+	b.Reset()
+	if err := p.HTML(&b, nil, pkgURL); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); !strings.Contains(got, "<p>Package a prints with f.Println and [f.Println].") {
+		t.Errorf("expected no links to other packages in\n%s", got)
+	}
+}
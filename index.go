@@ -0,0 +1,207 @@
+package goutil
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+//A Ref is an identifier referring to a declaration.
+type Ref struct {
+	//Package the identifier is in.
+	Package *Package
+	Ident   *ast.Ident
+	//Test is set if the identifier is in a test file of Package,
+	//including the files of its external test package.
+	Test bool
+	//the unit the identifier was type checked in.
+	unit *unit
+}
+
+//Position returns the position of the identifier.
+func (r Ref) Position() token.Position {
+	return r.Package.FileSet.Position(r.Ident.Pos())
+}
+
+//An Index maps every top level declaration, including methods, in a set
+//of Packages to every identifier in those Packages, and in their test
+//files, referring to it.
+type Index struct {
+	Packages Packages
+	refs     map[types.Object][]Ref
+	units    []*unit
+}
+
+//A unit is a set of files of a Package type checked together:
+//the files of the package, its test files checked with the files of the
+//package, or the files of its external test package.
+type unit struct {
+	p     *Package
+	types *types.Package
+	info  *types.Info
+	//files are the files of the unit that are indexed, which, for the
+	//package checked with its test files, are only the test files.
+	files          []*ast.File
+	test, external bool
+	//decls maps the position of every identifier declared in p's own files
+	//to its object in p.Types, so that the objects of the package checked
+	//with its test files may be mapped to those of p.Types.
+	decls map[token.Pos]types.Object
+}
+
+//object returns the object of the files of u referred to by obj,
+//which, if obj is declared in p's own files, is the object in p.Types.
+func (u *unit) object(obj types.Object) types.Object {
+	obj = origin(obj)
+	if u.decls != nil && obj.Pkg() == u.types {
+		if o, ok := u.decls[obj.Pos()]; ok {
+			return o
+		}
+	}
+	return obj
+}
+
+//units returns the units of p, which must have been type checked.
+func (p *Package) units() []*unit {
+	u := &unit{p: p, types: p.Types, info: p.Info, files: p.astFiles()}
+	return append([]*unit{u}, p.testUnits()...)
+}
+
+//toplevel reports whether obj is declared at package level or is a method.
+func toplevel(obj types.Object) bool {
+	if obj.Pkg() == nil {
+		return false
+	}
+	if f, ok := obj.(*types.Func); ok && f.Type().(*types.Signature).Recv() != nil {
+		return true
+	}
+	return obj.Parent() == obj.Pkg().Scope()
+}
+
+//origin returns the generic object an instantiated object derives from.
+func origin(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
+//Index type checks each package and creates an Index over them.
+//
+//The packages need only contain the packages whose references are of
+//interest. For example, to find every use of a package's API within
+//a tree, use the Packages from ImportTree, or to find what a program
+//uses of its dependencies, use the Packages from ImportDeps.
+//
+//The test files of each package are type checked as well, both those
+//of the package and those of its external test package, and their
+//references are included. Type errors in test files are not reported.
+//
+//As with TypeCheck, if there are type errors the first is returned along
+//with an Index of what could be checked.
+func (ps Packages) Index() (*Index, error) {
+	err := ps.TypeCheck()
+	ix := &Index{
+		Packages: ps,
+		refs:     map[types.Object][]Ref{},
+	}
+	for _, p := range ps {
+		if p.Info == nil {
+			continue
+		}
+		ix.units = append(ix.units, p.units()...)
+	}
+	for _, u := range ix.units {
+		for _, f := range u.files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				if obj := u.info.Uses[id]; obj != nil && toplevel(obj) {
+					obj = u.object(obj)
+					ix.refs[obj] = append(ix.refs[obj], Ref{u.p, id, u.test, u})
+				}
+				return true
+			})
+		}
+	}
+	return ix, err
+}
+
+//Refs returns every reference to obj in the Index, in order
+//of package and then position, with the references in the test files
+//of a package after those in the package.
+func (ix *Index) Refs(obj types.Object) []Ref {
+	return ix.refs[obj]
+}
+
+//Objects returns the objects declared by d, which must be from p.
+func (p *Package) Objects(d ast.Decl) (objs []types.Object) {
+	for _, id := range Idents(d) {
+		if obj := p.Info.Defs[id]; obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+	return
+}
+
+//References returns every reference to the objects declared by d,
+//which must be from p.
+func (ix *Index) References(p *Package, d ast.Decl) (refs []Ref) {
+	for _, obj := range p.Objects(d) {
+		refs = append(refs, ix.refs[obj]...)
+	}
+	return
+}
+
+//Unused returns the Decls of p, split by SplitSpecs, that declare nothing
+//referred to in the Index. References within a declaration to itself,
+//such as a recursive call, are not counted. The functions init and, in
+//package main, main, are never reported.
+//
+//If external is true, references from p itself are also not counted,
+//so that Unused reports the exported API of p that none of the other
+//packages in the Index use.
+//
+//Declarations only of _ are never reported, nor, if external is true,
+//are declarations of unexported names.
+//
+//References from test files, including those of external test packages,
+//are counted only if tests is true. Otherwise, a declaration used only
+//by tests is reported.
+//
+//Note that a method called only through an interface is not referred to.
+func (ix *Index) Unused(p *Package, external, tests bool) (out Decls) {
+	for _, d := range p.Decls().SplitSpecs() {
+		blank, exported := true, false
+		for _, id := range Idents(d) {
+			blank = blank && id.Name == "_"
+			exported = exported || id.IsExported()
+		}
+		if blank || (external && !exported) {
+			continue
+		}
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv == nil {
+			if nm := fd.Name.Name; nm == "init" || (nm == "main" && p.Build.Name == "main") {
+				continue
+			}
+		}
+
+		used := false
+		for _, r := range ix.References(p, d) {
+			if (r.Test && !tests) || (r.Package == p && (external || (r.Ident.Pos() >= d.Pos() && r.Ident.End() <= d.End()))) {
+				continue
+			}
+			used = true
+			break
+		}
+		if !used {
+			out = append(out, d)
+		}
+	}
+	return
+}
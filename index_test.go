@@ -0,0 +1,138 @@
+package goutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const indexSrc = `package a
+
+import "strings"
+
+func used() string { return strings.Repeat("x", 2) }
+
+func Unused() {}
+
+func rec(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return rec(n - 1)
+}
+
+var V = used()
+
+type T struct{}
+
+func (T) M() {}
+
+func init() { T{}.M() }
+
+const _ = 1
+`
+
+func TestIndex(t *testing.T) {
+	p := importSrc(t, indexSrc)
+	ix, err := Packages{p}.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repeat := p.Types.Imports()[0].Scope().Lookup("Repeat")
+	refs := ix.Refs(repeat)
+	if len(refs) != 1 || refs[0].Package != p || refs[0].Position().Line != 5 {
+		t.Errorf("expected one reference to strings.Repeat on line 5, got %v", refs)
+	}
+
+	ds := p.Decls().SplitSpecs()
+	table := []struct {
+		name string
+		refs []int
+	}{
+		{"used", []int{16}},
+		{"Unused", nil},
+		{"rec", []int{13}},
+		{"T", []int{20, 22}},
+		{"M", []int{22}},
+	}
+	for _, c := range table {
+		d := ds.Named(Exact(c.name))
+		if len(d) != 1 {
+			t.Fatalf("expected one declaration of %s", c.name)
+		}
+		var lines []int
+		for _, r := range ix.References(p, d[0]) {
+			lines = append(lines, r.Position().Line)
+		}
+		if fmt.Sprint(lines) != fmt.Sprint(c.refs) {
+			t.Errorf("%s: expected references on lines %v, got %v", c.name, c.refs, lines)
+		}
+	}
+
+	for _, c := range []struct {
+		external bool
+		out      string
+	}{
+		{false, "Unused rec V"},
+		{true, "Unused V T M"},
+	} {
+		var acc []string
+		for _, d := range ix.Unused(p, c.external, false) {
+			acc = append(acc, Idents(d)[0].Name)
+		}
+		if out := strings.Join(acc, " "); out != c.out {
+			t.Errorf("external %v: got %q expected %q", c.external, out, c.out)
+		}
+	}
+}
+
+func TestIndexTestFiles(t *testing.T) {
+	p := importFiles(t, map[string]string{
+		"a.go":      indexSrc,
+		"a_test.go": "package a\n\nfunc helper() { Unused() }\n",
+	})
+	ix, err := Packages{p}.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := p.Decls().SplitSpecs().Named(Exact("Unused"))
+	refs := ix.References(p, d[0])
+	if len(refs) != 1 || !refs[0].Test || refs[0].Position().Line != 3 {
+		t.Errorf("expected one reference to Unused from a test file on line 3, got %v", refs)
+	}
+	//references from tests count as uses only if asked.
+	for _, c := range []struct {
+		tests bool
+		out   string
+	}{
+		{false, "Unused rec V"},
+		{true, "rec V"},
+	} {
+		var acc []string
+		for _, d := range ix.Unused(p, false, c.tests) {
+			acc = append(acc, Idents(d)[0].Name)
+		}
+		if out := strings.Join(acc, " "); out != c.out {
+			t.Errorf("tests %v: got %q expected %q", c.tests, out, c.out)
+		}
+	}
+
+	//a test using a declaration of the same name in another package
+	//does not use this one.
+	other := importFiles(t, map[string]string{
+		"b.go":      "package b\n\nfunc Unused() {}\n",
+		"b_test.go": "package b\n\nfunc helper() { Unused() }\n",
+	})
+	p = importFile(t, indexSrc)
+	ix, err = Packages{p, other}.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := ix.Unused(p, false, true).Named(Exact("Unused")); len(out) != 1 {
+		t.Error("expected Unused of a to be unused despite the test of b")
+	}
+	if out := ix.Unused(other, false, true).Named(Exact("Unused")); len(out) != 0 {
+		t.Error("expected Unused of b to be used by its test")
+	}
+}
@@ -0,0 +1,276 @@
+package goutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//manRef matches a reference to a manual page, such as go(1).
+var manRef = regexp.MustCompile(`\b([A-Za-z][\w.+-]*)\(([1-9])\)`)
+
+//roffEscape escapes text for roff.
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	return strings.Replace(s, "-", `\-`, -1)
+}
+
+//roffLine escapes a line of text for roff, so that it is not
+//taken for a request.
+func roffLine(s string) string {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + roffEscape(s)
+	}
+	return roffEscape(s)
+}
+
+//roffQuote returns s as a quoted argument of a roff request.
+func roffQuote(s string) string {
+	return `"` + strings.Replace(roffEscape(s), `"`, `\(dq`, -1) + `"`
+}
+
+//roff writes the body of a man page.
+type roff struct {
+	bytes.Buffer
+	//the manual pages referred to, by name and section.
+	refs map[string]bool
+}
+
+//text writes the line s, escaped, with any manual page references in bold.
+func (r *roff) text(s string) {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		r.WriteString(`\&`)
+	}
+	for {
+		m := manRef.FindStringSubmatchIndex(s)
+		if m == nil {
+			break
+		}
+		r.refs[s[m[0]:m[1]]] = true
+		r.WriteString(roffEscape(s[:m[0]]))
+		fmt.Fprintf(r, `\fB%s\fR(%s)`, roffEscape(s[m[2]:m[3]]), s[m[4]:m[5]])
+		s = s[m[1]:]
+	}
+	r.WriteString(roffEscape(s))
+}
+
+//para writes the lines of a Para.
+func (r *roff) para(b Block) {
+	for i, line := range b.Lines {
+		line = strings.TrimSuffix(line, "\n")
+		if i >= len(b.Spans) || b.Spans[i] == nil {
+			r.text(line)
+			r.WriteString("\n")
+			continue
+		}
+		var s bytes.Buffer
+		for _, sp := range b.Spans[i] {
+			s.WriteString(sp.Text)
+			if sp.Kind == LinkSpan && sp.Doc == nil && sp.URL != sp.Text {
+				s.WriteString(" <" + sp.URL + ">")
+			}
+		}
+		r.text(s.String())
+		r.WriteString("\n")
+	}
+}
+
+//blocks writes bs, starting a new paragraph if first is false.
+func (r *roff) blocks(bs []Block, first bool) {
+	for _, b := range bs {
+		switch b.Kind {
+		case Para:
+			if !first {
+				r.WriteString(".PP\n")
+			}
+			r.para(b)
+		case Head:
+			fmt.Fprintf(r, ".SS %s\n", roffQuote(b.Lines[0]))
+		case Pre:
+			if !first {
+				r.WriteString(".PP\n")
+			}
+			r.WriteString(".RS 4\n.nf\n")
+			for _, line := range b.Lines {
+				r.WriteString(roffLine(strings.TrimSuffix(line, "\n")) + "\n")
+			}
+			r.WriteString(".fi\n.RE\n")
+		case List:
+			for _, it := range b.Items {
+				if it.Number != "" {
+					fmt.Fprintf(r, ".IP %s 4\n", roffQuote(it.Number+"."))
+				} else {
+					r.WriteString(".IP \\(bu 2\n")
+				}
+				for i, p := range it.Blocks {
+					if i > 0 {
+						r.WriteString(".IP\n")
+					}
+					r.para(p)
+				}
+			}
+			//end the list.
+			r.WriteString(".PP\n")
+			first = true
+			continue
+		}
+		first = false
+	}
+}
+
+//usage returns the usage line of the main function of p,
+//such as %name %flags [packages].
+func (p *Package) usage() string {
+	for name := range p.AST.Files {
+		src, err := p.file(name)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, d := range f.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil || fd.Name.Name != "main" || fd.Doc == nil {
+				continue
+			}
+			for _, line := range strings.Split(fd.Doc.Text(), "\n") {
+				if strings.HasPrefix(line, "Usage:") {
+					return strings.TrimSpace(strings.TrimPrefix(line, "Usage:"))
+				}
+			}
+		}
+	}
+	return ""
+}
+
+//Man writes a manual page for the command p to w in roff, the format read
+//by man(1).
+//
+//The NAME is the name of the command and the first sentence of its
+//documentation, and the DESCRIPTION is the rest of its documentation.
+//The OPTIONS are the flags found by Flags.
+//The SYNOPSIS follows the usage line of the doc comment of the main
+//function, if it has one, such as
+//	//Usage: %name %flags regexp [package|directory]
+//where %name is replaced by the name of the command and %flags by its flags.
+//The pages referred to in the documentation, such as go(1), are listed
+//under SEE ALSO.
+//
+//The page is dated date, unless it is the zero time.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) Man(w io.Writer, date time.Time) error {
+	if p.Build.Name != "main" {
+		return fmt.Errorf("%s is not a command", p.Build.ImportPath)
+	}
+	if p.Doc == nil {
+		return errors.New("Package documentation has not been parsed")
+	}
+	flags, err := p.Flags()
+	if err != nil {
+		return err
+	}
+	//the name go install gives the command.
+	name := filepath.Base(p.Build.Dir)
+	r := &roff{refs: map[string]bool{}}
+
+	fmt.Fprintf(r, ".TH %s 1", roffQuote(strings.ToUpper(name)))
+	if !date.IsZero() {
+		fmt.Fprintf(r, " \"%s\"", date.Format("2006-01-02"))
+	}
+	r.WriteString("\n.SH NAME\n")
+	synopsis := doc.Synopsis(p.Doc.Doc)
+	r.text(name + " - " + synopsis)
+	r.WriteString("\n")
+
+	r.WriteString(".SH SYNOPSIS\n")
+	usage := p.usage()
+	if usage == "" {
+		usage = "%name %flags"
+	}
+	for _, arg := range strings.Fields(usage) {
+		switch arg {
+		case "%name":
+			fmt.Fprintf(r, ".B %s\n", roffEscape(name))
+		case "%flags":
+			for _, f := range flags {
+				if f.Arg == "" {
+					fmt.Fprintf(r, "[\\fB\\-%s\\fR]\n", roffEscape(f.Name))
+				} else {
+					fmt.Fprintf(r, "[\\fB\\-%s\\fR \\fI%s\\fR]\n", roffEscape(f.Name), roffEscape(f.Arg))
+				}
+			}
+		default:
+			r.text(arg)
+			r.WriteString("\n")
+		}
+	}
+
+	//the first paragraph is the NAME, if it is a single sentence.
+	blocks := ParseDoc(p.Doc.Doc).Blocks
+	if len(blocks) > 0 && blocks[0].Kind == Para {
+		first := strings.Join(strings.Fields(strings.Join(blocks[0].Lines, "")), " ")
+		if first == synopsis {
+			blocks = blocks[1:]
+		}
+	}
+	if len(blocks) > 0 {
+		r.WriteString(".SH DESCRIPTION\n")
+		r.blocks(blocks, true)
+	}
+
+	if len(flags) > 0 {
+		r.WriteString(".SH OPTIONS\n")
+		for _, f := range flags {
+			r.WriteString(".TP\n")
+			if f.Arg == "" {
+				fmt.Fprintf(r, ".B \\-%s\n", roffEscape(f.Name))
+			} else {
+				fmt.Fprintf(r, "\\fB\\-%s\\fR \\fI%s\\fR\n", roffEscape(f.Name), roffEscape(f.Arg))
+			}
+			usage := f.Usage
+			switch f.Default {
+			case "", "false", "0", `""`:
+			default:
+				usage += " (default " + f.Default + ")"
+			}
+			for _, line := range strings.Split(usage, "\n") {
+				r.text(line)
+				r.WriteString("\n")
+			}
+		}
+	}
+
+	delete(r.refs, name+"(1)")
+	if len(r.refs) > 0 {
+		var refs []string
+		for ref := range r.refs {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+		r.WriteString(".SH \"SEE ALSO\"\n")
+		for i, ref := range refs {
+			m := manRef.FindStringSubmatch(ref)
+			fmt.Fprintf(r, ".BR %s (%s)", roffEscape(m[1]), m[2])
+			if i < len(refs)-1 {
+				r.WriteString(",")
+			}
+			r.WriteString("\n")
+		}
+	}
+
+	_, err = w.Write(r.Bytes())
+	return err
+}
@@ -0,0 +1,105 @@
+package goutil
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const manSrc = `//Cmd does things, see ls(1).
+//
+//Cmd does them -quickly.
+//	.x
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+var (
+	n = flag.Int("n", 3, "run ` + "`count`" + ` times")
+	v = flag.Bool("v", false, "be verbose")
+	d time.Duration
+)
+
+func init() {
+	flag.DurationVar(&d, "d", 2*time.Second, "wait")
+	flag.String(name(), "", "not constant")
+}
+
+func name() string {
+	return "x"
+}
+
+//Usage: %name %flags file...
+func main() {}
+`
+
+func TestFlags(t *testing.T) {
+	flags, err := importFile(t, manSrc).Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Flag{
+		{Name: "n", Usage: "run count times", Arg: "count", Default: "3"},
+		{Name: "v", Usage: "be verbose", Default: "false"},
+		{Name: "d", Usage: "wait", Arg: "duration", Default: "2s"},
+	}
+	if len(flags) != len(expected) {
+		t.Fatalf("expected %d flags, got %d: %v", len(expected), len(flags), flags)
+	}
+	for i, f := range flags {
+		f.Pos = expected[i].Pos
+		if f != expected[i] {
+			t.Errorf("expected %#v, got %#v", expected[i], f)
+		}
+	}
+}
+
+func TestMan(t *testing.T) {
+	p := importFile(t, manSrc)
+	if err := p.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := p.Man(&b, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	name := roffEscape(filepath.Base(p.Build.Dir))
+	expected := `.TH "` + strings.ToUpper(name) + `" 1 "2020-01-02"
+.SH NAME
+` + name + ` \- Cmd does things, see \fBls\fR(1).
+.SH SYNOPSIS
+.B ` + name + `
+[\fB\-n\fR \fIcount\fR]
+[\fB\-v\fR]
+[\fB\-d\fR \fIduration\fR]
+file...
+.SH DESCRIPTION
+Cmd does them \-quickly.
+.PP
+.RS 4
+.nf
+\&.x
+.fi
+.RE
+.SH OPTIONS
+.TP
+\fB\-n\fR \fIcount\fR
+run count times (default 3)
+.TP
+.B \-v
+be verbose
+.TP
+\fB\-d\fR \fIduration\fR
+wait (default 2s)
+.SH "SEE ALSO"
+.BR ls (1)
+`
+	if got := b.String(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
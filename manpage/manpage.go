@@ -0,0 +1,82 @@
+//Manpage writes a manual page, in the roff format read by man(1), for a Go
+//command, or set of Go commands, from the command's documentation and
+//flags.
+//
+//The packages may be specified as with the go(1) tool, including the
+//special ... operator. Packages that are not commands are skipped.
+//Each page is written to its command's directory as name.1, where name is
+//the name go install gives the command, or, with -n, printed instead.
+//
+//The page is dated today, or by -date. The usage line of the page is taken
+//from the doc comment of the command's main function, as described
+//by the Man method of goutil.Package.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	show = flag.Bool("n", false, "print each page instead of writing it")
+	date = flag.String("date", "", "date each page `YYYY-MM-DD` instead of today")
+	tags = gocli.TagsFlag("")
+)
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	when := time.Now()
+	if *date != "" {
+		var err error
+		if when, err = time.Parse("2006-01-02", *date); err != nil {
+			fatal(err)
+		}
+	}
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+
+	for _, p := range gocli.Flatten(pss) {
+		if !p.Build.IsCommand() {
+			continue
+		}
+		if err = p.ParseDocs(0); err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		var b bytes.Buffer
+		if err = p.Man(&b, when); err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		if *show {
+			fmt.Printf("%s", b.Bytes())
+			continue
+		}
+		name := filepath.Base(p.Build.Dir) + ".1"
+		if err = ioutil.WriteFile(filepath.Join(p.Build.Dir, name), b.Bytes(), 0666); err != nil {
+			fatal(err)
+		}
+	}
+}
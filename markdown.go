@@ -0,0 +1,201 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//mdSpecial are the characters escaped anywhere in Markdown text.
+const mdSpecial = "\\`*_[]<>|"
+
+//mdEscape escapes the text of a line of Markdown,
+//which is at the start of the line if start is set.
+func mdEscape(s string, start bool) string {
+	var b bytes.Buffer
+	if start {
+		//characters that would start a heading, quote, or list.
+		trimmed := strings.TrimLeft(s, " ")
+		lead := s[:len(s)-len(trimmed)]
+		switch {
+		case trimmed == "":
+		case strings.IndexByte("#>+-=", trimmed[0]) >= 0:
+			b.WriteString(lead + "\\")
+			s = trimmed
+		default:
+			if num, _, ok := listMarker(trimmed); ok && num != "" {
+				b.WriteString(lead + num + "\\")
+				s = trimmed[len(num):]
+			}
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(mdSpecial, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+//mdFence returns a code fence longer than any run of backticks in src.
+func mdFence(src string) string {
+	fence := "```"
+	for strings.Contains(src, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+//markdown is a docWriter for GitHub flavored Markdown.
+type markdown struct {
+	bytes.Buffer
+}
+
+func (m *markdown) heading(level int, anchor, text string) {
+	if anchor != "" {
+		fmt.Fprintf(m, "<a name=\"%s\"></a>\n", anchor)
+	}
+	fmt.Fprintf(m, "%s %s\n\n", strings.Repeat("#", level), mdEscape(text, false))
+}
+
+func (m *markdown) code(lang, src string, indent string) {
+	src = strings.TrimSuffix(src, "\n")
+	fence := mdFence(src)
+	m.WriteString(indent + fence + lang + "\n")
+	for _, line := range strings.Split(src, "\n") {
+		if line != "" {
+			m.WriteString(indent)
+		}
+		m.WriteString(line + "\n")
+	}
+	m.WriteString(indent + fence + "\n\n")
+}
+
+func (m *markdown) decl(src string) {
+	m.code("go", src, "")
+}
+
+func (m *markdown) doc(d *Doc) {
+	m.blocks(d.Blocks, "")
+}
+
+func (m *markdown) index(entries []indexEntry) {
+	for _, e := range entries {
+		fmt.Fprintf(m, "%s- [%s](#%s)\n", strings.Repeat("  ", e.depth), mdEscape(e.text, false), e.anchor)
+	}
+	m.WriteString("\n")
+}
+
+//spans writes a line of a Para.
+func (m *markdown) spans(line string, spans []Span) {
+	if spans == nil {
+		m.WriteString(mdEscape(strings.TrimSuffix(line, "\n"), true))
+		return
+	}
+	for i, s := range spans {
+		switch s.Kind {
+		case URLSpan:
+			fmt.Fprintf(m, "<%s>", s.URL)
+		case IdentSpan:
+			url := "#" + s.Text
+			if s.Doc != nil {
+				url = docLinkURL(s.Doc, pkgGoDev)
+			}
+			fmt.Fprintf(m, "[%s](%s)", mdEscape(s.Text, false), url)
+		case LinkSpan:
+			url := s.URL
+			if s.Doc != nil {
+				url = docLinkURL(s.Doc, pkgGoDev)
+			}
+			fmt.Fprintf(m, "[%s](%s)", mdEscape(s.Text, false), url)
+		default:
+			m.WriteString(mdEscape(s.Text, i == 0))
+		}
+	}
+}
+
+//para writes the lines of a Para, the first prefixed by first
+//and the rest by indent.
+func (m *markdown) para(b Block, first, indent string) {
+	for i, line := range b.Lines {
+		if i == 0 {
+			m.WriteString(first)
+		} else {
+			m.WriteString(indent)
+		}
+		var spans []Span
+		if i < len(b.Spans) {
+			spans = b.Spans[i]
+		}
+		m.spans(line, spans)
+		m.WriteString("\n")
+	}
+}
+
+//blocks writes bs, each line prefixed by indent.
+func (m *markdown) blocks(bs []Block, indent string) {
+	for _, b := range bs {
+		switch b.Kind {
+		case Para:
+			m.para(b, indent, indent)
+			m.WriteString("\n")
+		case Head:
+			fmt.Fprintf(m, "%s### %s\n\n", indent, mdEscape(b.Lines[0], false))
+		case Pre:
+			m.code("", strings.Join(b.Lines, ""), indent)
+		case List:
+			for i, it := range b.Items {
+				marker := "- "
+				if it.Number != "" {
+					marker = it.Number + ". "
+				}
+				inner := indent + strings.Repeat(" ", len(marker))
+				for j, p := range it.Blocks {
+					if j == 0 {
+						m.para(p, indent+marker, inner)
+					} else {
+						m.WriteString("\n")
+						m.para(p, inner, inner)
+					}
+				}
+				if b.Spaced && i < len(b.Items)-1 {
+					m.WriteString("\n")
+				}
+			}
+			m.WriteString("\n")
+		}
+	}
+}
+
+//Markdown writes blocks to w as GitHub flavored Markdown.
+//
+//Headings are written as level 3 headings. Pre blocks are written as
+//fenced code. Links to the documentation of other packages go to
+//pkg.go.dev, and links to declarations in the same package, and the
+//identifiers marked by MarkIdents, go to anchors of the same name, as
+//written by the Markdown method of Package.
+func Markdown(w io.Writer, blocks []Block) error {
+	var m markdown
+	m.blocks(blocks, "")
+	_, err := w.Write(m.Bytes())
+	return err
+}
+
+//Markdown writes the documentation of p to w as GitHub flavored Markdown,
+//as godoc would present it: the package overview, an index, and then
+//the documentation of each exported declaration, with the index, the
+//doc links in the documentation, and any methods of the package in the
+//documentation, such as Package.Parse, linked to their declarations,
+//as described for the HTML method.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) Markdown(w io.Writer) error {
+	var m markdown
+	if err := p.renderDoc(&m, nil); err != nil {
+		return err
+	}
+	_, err := w.Write(m.Bytes())
+	return err
+}
@@ -0,0 +1,104 @@
+package goutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	const text = `Package x uses *T, see https://go.dev and [io.Reader].
+
+Usage
+
+Steps are:
+  - one
+  - two
+
+Code:
+
+	x := a[0]
+`
+	const expected = "Package x uses \\*T, see <https://go.dev> and [io.Reader](https://pkg.go.dev/io#Reader).\n" +
+		"\n" +
+		"### Usage\n" +
+		"\n" +
+		"Steps are:\n" +
+		"\n" +
+		"- one\n" +
+		"- two\n" +
+		"\n" +
+		"Code:\n" +
+		"\n" +
+		"```\n" +
+		"x := a[0]\n" +
+		"```\n" +
+		"\n"
+	var b bytes.Buffer
+	if err := Markdown(&b, DocParse(text)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+const pkgMarkdownSrc = `//Package a builds a Context from a go/build Context, see [New].
+package a
+
+import "go/build"
+
+//Max is the most Contexts, see New.
+const Max = 2
+
+//A Context wraps a build.Context.
+type Context struct {
+	build.Context
+}
+
+//New returns a new [Context], as does Context.Copy.
+func New() *Context { return nil }
+
+//Copy returns a copy of c. Compare [New].
+func (c *Context) Copy() *Context { return c }
+
+//Package is a package.
+type Package int
+
+func unexported() {}
+`
+
+func TestPackageMarkdown(t *testing.T) {
+	p := importFiles(t, map[string]string{"a.go": pkgMarkdownSrc})
+	if err := p.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := p.Markdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	//only doc links and methods are linked, so neither the Package of the
+	//package clause nor the Context of go/build is taken for the package's
+	//own, and a declaration is not linked from its own documentation.
+	expected := []string{
+		"# package a\n",
+		"Package a builds a Context from a go/build Context, see [New](#New).\n",
+		"- [Constants](#pkg-constants)\n- [type Context](#Context)\n  - [func New() \\*Context](#New)\n  - [func (c \\*Context) Copy() \\*Context](#Context.Copy)\n- [type Package](#Package)\n",
+		"<a name=\"pkg-constants\"></a>\n## Constants\n\n```go\nconst Max = 2\n```\n\nMax is the most Contexts, see New.\n",
+		"<a name=\"Context\"></a>\n### type Context\n\n```go\ntype Context struct {\n\tbuild.Context\n}\n```\n\nA Context wraps a build.Context.\n",
+		"<a name=\"New\"></a>\n### func New\n",
+		"New returns a new [Context](#Context), as does [Context.Copy](#Context.Copy).\n",
+		"<a name=\"Context.Copy\"></a>\n### func (c \\*Context) Copy\n",
+		"Copy returns a copy of c. Compare [New](#New).\n",
+		"Package is a package.\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("expected\n%s\nin\n%s", e, got)
+		}
+	}
+	if strings.Contains(got, "unexported") {
+		t.Errorf("unexported declaration documented in\n%s", got)
+	}
+}
@@ -0,0 +1,156 @@
+package goutil
+
+import (
+	"path"
+	"strings"
+)
+
+type allmatcher []StringMatcher
+
+func (a allmatcher) MatchString(s string) bool {
+	for _, m := range a {
+		if !m.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+//All returns a StringMatcher that matches strings matched by every one of ms.
+//If ms is empty, every string is matched.
+func All(ms ...StringMatcher) StringMatcher {
+	return allmatcher(ms)
+}
+
+type anymatcher []StringMatcher
+
+func (a anymatcher) MatchString(s string) bool {
+	for _, m := range a {
+		if m.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+//Any returns a StringMatcher that matches strings matched by at least one of ms.
+//If ms is empty, no string is matched.
+func Any(ms ...StringMatcher) StringMatcher {
+	return anymatcher(ms)
+}
+
+type notmatcher struct {
+	m StringMatcher
+}
+
+func (n notmatcher) MatchString(s string) bool {
+	return !n.m.MatchString(s)
+}
+
+//Not returns a StringMatcher that matches strings m does not.
+func Not(m StringMatcher) StringMatcher {
+	return notmatcher{m}
+}
+
+type foldmatcher struct {
+	m StringMatcher
+}
+
+func (f foldmatcher) MatchString(s string) bool {
+	return f.m.MatchString(strings.ToLower(s))
+}
+
+//fold returns m with its pattern in lower case, if m is one of the
+//matchers of this package whose pattern is known.
+func fold(m StringMatcher) StringMatcher {
+	switch x := m.(type) {
+	case PrefixMatcher:
+		return PrefixMatcher(strings.ToLower(string(x)))
+	case SuffixMatcher:
+		return SuffixMatcher(strings.ToLower(string(x)))
+	case GlobMatcher:
+		return GlobMatcher(strings.ToLower(string(x)))
+	case ExactMatcher:
+		e := ExactMatcher{}
+		for s := range x {
+			e[strings.ToLower(s)] = true
+		}
+		return e
+	case allmatcher:
+		out := make(allmatcher, len(x))
+		for i, m := range x {
+			out[i] = fold(m)
+		}
+		return out
+	case anymatcher:
+		out := make(anymatcher, len(x))
+		for i, m := range x {
+			out[i] = fold(m)
+		}
+		return out
+	case notmatcher:
+		return notmatcher{fold(x.m)}
+	}
+	return m
+}
+
+//IgnoreCase returns a StringMatcher that matches strings whose lower case
+//form is matched by m with its pattern in lower case, so that
+//	IgnoreCase(PrefixMatcher("New"))
+//matches "NewGostrap" and "newer".
+//
+//The patterns of the PrefixMatcher, SuffixMatcher, ExactMatcher, and
+//GlobMatcher types, including those combined by All, Any, and Not,
+//are put in lower case. Any other matcher is given the lower case form
+//of the string as is, so it must have been created from a lower case
+//pattern. For a regexp, use the (?i) flag instead.
+func IgnoreCase(m StringMatcher) StringMatcher {
+	if f, ok := m.(foldmatcher); ok {
+		return f
+	}
+	return foldmatcher{fold(m)}
+}
+
+//SuffixMatcher matches all strings with specified suffix.
+type SuffixMatcher string
+
+//MatchString matches strings with the suffix set
+func (x SuffixMatcher) MatchString(s string) bool {
+	return strings.HasSuffix(s, string(x))
+}
+
+//ExactMatcher matches only the strings in the set.
+type ExactMatcher map[string]bool
+
+//Exact returns an ExactMatcher matching only the strings ss.
+func Exact(ss ...string) ExactMatcher {
+	e := ExactMatcher{}
+	for _, s := range ss {
+		e[s] = true
+	}
+	return e
+}
+
+//MatchString matches strings in the set
+func (e ExactMatcher) MatchString(s string) bool {
+	return e[s]
+}
+
+//GlobMatcher matches strings against a shell glob, as described by
+//path.Match. A malformed pattern matches nothing.
+type GlobMatcher string
+
+//MatchString matches strings against the glob
+func (g GlobMatcher) MatchString(s string) bool {
+	ok, _ := path.Match(string(g), s)
+	return ok
+}
+
+//Glob returns a GlobMatcher for pattern, after checking that the pattern is
+//well formed.
+func Glob(pattern string) (GlobMatcher, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	return GlobMatcher(pattern), nil
+}
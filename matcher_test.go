@@ -0,0 +1,111 @@
+package goutil
+
+import (
+	"testing"
+)
+
+var matchers = []struct {
+	m       StringMatcher
+	matches []string
+	misses  []string
+}{
+	{
+		All(PrefixMatcher("New"), SuffixMatcher("er")),
+		[]string{"NewMatcher", "Newer"},
+		[]string{"NewGostrap", "Matcher"},
+	},
+	{
+		Any(Exact("a", "b"), PrefixMatcher("c")),
+		[]string{"a", "b", "cat"},
+		[]string{"ab", "d"},
+	},
+	{
+		Not(PrefixMatcher("_")),
+		[]string{"x", ""},
+		[]string{"_", "_x"},
+	},
+	{
+		IgnoreCase(Exact("Decls")),
+		[]string{"Decls", "DECLS", "decls"},
+		[]string{"Decl"},
+	},
+	{
+		IgnoreCase(PrefixMatcher("New")),
+		[]string{"NewGostrap", "newer", "NEW"},
+		[]string{"Ne", "renew"},
+	},
+	{
+		IgnoreCase(All(Not(SuffixMatcher("Er")), GlobMatcher("[A-Z]*"))),
+		[]string{"Parse", "parse"},
+		[]string{"Parser", "_x"},
+	},
+	{
+		GlobMatcher("Parse*"),
+		[]string{"Parse", "ParseDocs"},
+		[]string{"DocParse"},
+	},
+	{
+		GlobMatcher("[A-Z]?"),
+		[]string{"Ab"},
+		[]string{"ab", "A"},
+	},
+	{
+		All(),
+		[]string{"", "x"},
+		nil,
+	},
+	{
+		Any(),
+		nil,
+		[]string{"", "x"},
+	},
+}
+
+func TestMatchers(t *testing.T) {
+	for i, tc := range matchers {
+		for _, s := range tc.matches {
+			if !tc.m.MatchString(s) {
+				t.Errorf("%d: %q should match", i, s)
+			}
+		}
+		for _, s := range tc.misses {
+			if tc.m.MatchString(s) {
+				t.Errorf("%d: %q should not match", i, s)
+			}
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	if _, err := Glob("[a-"); err == nil {
+		t.Error("expected bad pattern")
+	}
+}
+
+var fuzzy = []struct {
+	pattern string
+	better  string
+	worse   string
+}{
+	{"NGS", "NewGoStruct", "NewGostrap"},
+	{"NGS", "NewGostrap", "ImportingStuff"},
+	{"ngs", "NewGostrap", "nongost"},
+	{"Parse", "Parse", "ParseDocs"},
+	{"pd", "ParseDocs", "SplitDecls"},
+	{"hs", "HTTPServer", "HTTPSERVER"},
+}
+
+func TestFuzzy(t *testing.T) {
+	for i, tc := range fuzzy {
+		f := FuzzyMatcher(tc.pattern)
+		b, w := f.Score(tc.better), f.Score(tc.worse)
+		if b == 0 || b <= w {
+			t.Errorf("%d: %s %d ≤ %s %d", i, tc.better, b, tc.worse, w)
+		}
+	}
+	for _, s := range []string{"NG", "GNS", "NewGotrap"} {
+		if FuzzyMatcher("NGS").MatchString(s) {
+			t.Errorf("NGS should not match %s", s)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"go/doc"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
 )
@@ -23,8 +24,38 @@ type Package struct {
 	AST     *ast.Package
 	FileSet *token.FileSet //The FileSet AST was parsed with.
 	Doc     *doc.Package
+	Types   *types.Package //Set by TypeCheck.
+	Info    *types.Info    //Set by TypeCheck.
+	//DocFiles, if not nil, are the files whose package comments are
+	//the documentation of the package, as an override of the package's own.
+	//They are relative to Build.Dir, unless absolute, and are read
+	//regardless of their build constraints and package clauses.
+	//If nil, ParseDocs uses the files ignored by Build that are named doc.go
+	//and in the package, or whose package clause is "package documentation",
+	//unless the package is itself named documentation.
+	//To have an effect, DocFiles must be set before calling ParseDocs.
+	DocFiles []string
+	//DocSources are the files, as in Build.GoFiles or DocFiles,
+	//whose package comments are Doc.Doc, in order. Set by ParseDocs.
+	DocSources []string
 	//filename → tag
 	tags map[string]tag
+	//filename → contents, for Source
+	src map[string][]byte
+	//filename → whether the file is generated, set by Parse
+	generated map[string]bool
+	//the FileSet of the parse made by ParseDocs,
+	//for printing the declarations in Doc.
+	docFileSet *token.FileSet
+	//filename → import path → name given the import, for the files
+	//parsed by ParseDocs.
+	docImports map[string]map[string]string
+	//first error from TypeCheck, and whether TypeCheck is in progress.
+	typeErr  error
+	checking bool
+	//the test files type checked by testUnits, and whether they have been.
+	tests        []*unit
+	testsChecked bool
 }
 
 //ParseTags parses the build tags for each file in Build.GoFiles.
@@ -115,6 +146,7 @@ func (p *Package) parse(pc bool) (*ast.Package, *token.FileSet, error) {
 }
 
 //Parse the package and set p.AST.
+//Parse also records which files are generated, as reported by Generated.
 //
 //It is not necessary to call with parseComments if you intend to call
 //ParseDocs, as ParseDocs creates its own parse.
@@ -123,13 +155,20 @@ func (p *Package) Parse(parseComments bool) error {
 		return nil
 	}
 
-	pkg, fs, err := p.parse(parseComments)
+	//the header of a generated file is a comment, so comments are always
+	//parsed and, if not wanted, removed once the header is found.
+	pkg, fs, err := p.parse(true)
 	if err != nil {
 		return err
 	}
+	gen := generatedFiles(pkg)
+	if !parseComments {
+		stripComments(pkg)
+	}
 
 	p.AST = pkg
 	p.FileSet = fs
+	p.generated = gen
 	return nil
 }
 
@@ -137,10 +176,13 @@ func (p *Package) Parse(parseComments bool) error {
 //
 //If you do not need a particular doc.Mode call this with 0.
 //
-//If the package directory contains a file of package documentation
-//(and the package is not itself named documentation), it is parsed
-//and its doc.Package.Doc string replaces the string generated
-//by the package itself.
+//The package doc comment may be kept in files that are not built with
+//the package, as described by DocFiles. If any of these files has a package
+//comment, the package comments of those files replace the package's own
+//in Doc.Doc. DocSources records the files that Doc.Doc came from.
+//
+//The examples in the package's test files are attached to the declarations
+//they are named for, as described by Examples.
 //
 //Note that the go/doc package munges the AST so this method parses the AST
 //again, regardless of the value in p.AST. As a consequence, it is valid
@@ -150,50 +192,23 @@ func (p *Package) ParseDocs(mode doc.Mode) error {
 	if p.Doc != nil {
 		return nil
 	}
-	pkg, _, err := p.parse(true)
+	pkg, fs, err := p.parse(true)
 	if err != nil {
 		return err
 	}
 
-	p.Doc = doc.New(pkg, p.Build.ImportPath, mode)
-
-	//we don't want the below running if we happen to be importing a package
-	//whose name happens to be documentation.
-	if p.Build.Name == "documentation" {
-		return nil
-	}
-
-	//check ignored files for any package named documentation.
-	//assume there is only one such file.
-	//We ignore errors here as the ignored files may not be meant to parse.
-	var docfile string
-	for _, u := range p.Build.IgnoredGoFiles {
-		path := filepath.Join(p.Build.Dir)
-		fs := token.NewFileSet()
-		f, err := parser.ParseFile(fs, path, nil, parser.PackageClauseOnly)
-		if err != nil {
-			continue
-		}
-		if f.Name.Name == "documentation" {
-			docfile = u
-			break
-		}
+	own := commentedFiles(pkg)
+	imports := fileImports(pkg)
+	d, err := doc.NewFromFiles(fs, p.docInputFiles(pkg, fs), p.Build.ImportPath, mode)
+	if err != nil {
+		return err
 	}
-
-	//there's an ignored file of package documentation,
-	//parse it and replace the package doc string with this doc string.
-	if docfile != "" {
-		fs := token.NewFileSet()
-		f := func(fi os.FileInfo) bool {
-			return !fi.IsDir() && fi.Name() == docfile
-		}
-		pkgs, err := parser.ParseDir(fs, p.Build.Dir, f, parser.ParseComments)
-		if err != nil {
-			return err
-		}
-		d := doc.New(pkgs["documentation"], p.Build.ImportPath, 0)
-		p.Doc.Doc = d.Doc
+	if err = p.docSources(d, own); err != nil {
+		return err
 	}
 
+	p.docFileSet = fs
+	p.docImports = imports
+	p.Doc = d
 	return nil
 }
@@ -44,6 +44,20 @@ func (ps Packages) ParseTags() (err error) {
 	return
 }
 
+//TypeCheck type checks each package in turn.
+//
+//Unlike the other methods, TypeCheck does not stop at the first error,
+//as type errors in one package do not prevent checking the rest.
+//The first error encountered is returned.
+func (ps Packages) TypeCheck() (first error) {
+	for _, p := range ps {
+		if err := p.TypeCheck(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return
+}
+
 //Filter returns a sublist of packages that match the predicate f.
 //
 //If the predicate requires the Packages to be parsed or have their docs
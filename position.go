@@ -0,0 +1,119 @@
+package goutil
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//Idents returns the identifiers declared by d, in order.
+func Idents(d ast.Decl) (ids []*ast.Ident) {
+	switch dt := d.(type) {
+	case *ast.FuncDecl:
+		ids = append(ids, dt.Name)
+	case *ast.GenDecl:
+		for _, s := range dt.Specs {
+			switch st := s.(type) {
+			case *ast.TypeSpec:
+				ids = append(ids, st.Name)
+			case *ast.ValueSpec:
+				ids = append(ids, st.Names...)
+			}
+		}
+	}
+	return
+}
+
+//DeclDoc returns the doc comment of d, or nil.
+func DeclDoc(d ast.Decl) *ast.CommentGroup {
+	switch dt := d.(type) {
+	case *ast.FuncDecl:
+		return dt.Doc
+	case *ast.GenDecl:
+		return dt.Doc
+	}
+	return nil
+}
+
+//A DeclPosition is the location of a declaration in its source file.
+//Each token.Position includes the byte offset in the file, as well as
+//the line and column.
+type DeclPosition struct {
+	//Name is the position of the first identifier declared.
+	Name token.Position
+	//Start and End bound the declaration, excluding its doc comment.
+	//End is the position immediately after the declaration.
+	Start, End token.Position
+	//Doc is the start of the doc comment, or Start if there is none.
+	Doc token.Position
+	//Shared is set when the declaration is one of several names split by
+	//SplitSpecs from a single spec, such as b in
+	//	var a, b, c = 1, 2, 3
+	//There is no source of b alone, so Start and End bound
+	//the whole spec, which declares a and c as well.
+	Shared bool
+}
+
+//sharedSpec returns the spec of p that d, a GenDecl split by SplitSpecs,
+//was split from, and its GenDecl, if the spec declares more names than d.
+func (p *Package) sharedSpec(d *ast.GenDecl) (*ast.GenDecl, *ast.ValueSpec) {
+	if len(d.Specs) != 1 {
+		return nil, nil
+	}
+	vs, ok := d.Specs[0].(*ast.ValueSpec)
+	if !ok || len(vs.Names) == 0 {
+		return nil, nil
+	}
+	f := p.AST.Files[p.FileSet.Position(d.Pos()).Filename]
+	if f == nil {
+		return nil, nil
+	}
+	id := vs.Names[0]
+	for _, fd := range f.Decls {
+		g, ok := fd.(*ast.GenDecl)
+		if !ok || id.Pos() < g.Pos() || id.End() > g.End() {
+			continue
+		}
+		for _, s := range g.Specs {
+			orig, ok := s.(*ast.ValueSpec)
+			if !ok || orig == vs || len(orig.Names) == len(vs.Names) {
+				continue
+			}
+			for _, nm := range orig.Names {
+				if nm == id {
+					return g, orig
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+//Position returns the location of d, which must be from p.
+//
+//For a GenDecl split by SplitSpecs, this is the location of the spec
+//itself rather than that of its original GenDecl, unless the spec
+//is shared with other names, as described by DeclPosition.Shared.
+func (p *Package) Position(d ast.Decl) (pos DeclPosition) {
+	start, end := d.Pos(), d.End()
+	if g, ok := d.(*ast.GenDecl); ok {
+		if orig, spec := p.sharedSpec(g); spec != nil {
+			pos.Shared = true
+			start, end = spec.Pos(), spec.End()
+			if !orig.Lparen.IsValid() {
+				start = orig.Pos()
+			}
+		}
+	}
+	pos.Start = p.FileSet.Position(start)
+	pos.End = p.FileSet.Position(end)
+	pos.Doc = pos.Start
+	pos.Name = pos.Start
+	if ids := Idents(d); len(ids) > 0 {
+		pos.Name = p.FileSet.Position(ids[0].Pos())
+	}
+
+	if doc := DeclDoc(d); doc != nil && doc.Pos() < start {
+		pos.Doc = p.FileSet.Position(doc.Pos())
+	}
+	return
+}
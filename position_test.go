@@ -0,0 +1,81 @@
+package goutil
+
+import (
+	"testing"
+)
+
+const positionSrc = `package a
+
+//F is a func.
+func F() {}
+
+var (
+	//A is a var.
+	A = 1
+	B int
+)
+
+type T struct{}
+
+var C, D = 1, 2
+
+var (
+	//E and G are vars.
+	E, G = 3, 4
+)
+`
+
+func TestIdents(t *testing.T) {
+	table := []struct {
+		src, names string
+	}{
+		{"func F() {}", "F"},
+		{"func (T) M() {}", "M"},
+		{"type (\n\tT int\n\tU int\n)", "T,U"},
+		{"var a, b = 1, 2", "a,b"},
+		{"const (\n\tX = iota\n\tY\n)", "X,Y"},
+	}
+	for _, c := range table {
+		ds := parseDecls(t, c.src)
+		if got := names(Idents(ds[0])); got != c.names {
+			t.Errorf("%s: got %s expected %s", c.src, got, c.names)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	p := importFile(t, positionSrc)
+	ds := p.Decls().SplitSpecs()
+	table := []struct {
+		//the line and column of the name, start, end, and doc
+		name, start, end, doc [2]int
+		shared                bool
+	}{
+		{[2]int{4, 6}, [2]int{4, 1}, [2]int{4, 12}, [2]int{3, 1}, false},
+		{[2]int{8, 2}, [2]int{8, 2}, [2]int{8, 7}, [2]int{7, 2}, false},
+		{[2]int{9, 2}, [2]int{9, 2}, [2]int{9, 7}, [2]int{9, 2}, false},
+		{[2]int{12, 6}, [2]int{12, 1}, [2]int{12, 16}, [2]int{12, 1}, false},
+		{[2]int{14, 5}, [2]int{14, 1}, [2]int{14, 16}, [2]int{14, 1}, true},
+		{[2]int{14, 8}, [2]int{14, 1}, [2]int{14, 16}, [2]int{14, 1}, true},
+		{[2]int{18, 2}, [2]int{18, 2}, [2]int{18, 13}, [2]int{17, 2}, true},
+		{[2]int{18, 5}, [2]int{18, 2}, [2]int{18, 13}, [2]int{17, 2}, true},
+	}
+	if len(ds) != len(table) {
+		t.Fatalf("expected %d declarations, got %d", len(table), len(ds))
+	}
+	lc := func(l, c int) [2]int {
+		return [2]int{l, c}
+	}
+	for i, c := range table {
+		pos := p.Position(ds[i])
+		got := [4][2]int{
+			lc(pos.Name.Line, pos.Name.Column),
+			lc(pos.Start.Line, pos.Start.Column),
+			lc(pos.End.Line, pos.End.Column),
+			lc(pos.Doc.Line, pos.Doc.Column),
+		}
+		if got != [4][2]int{c.name, c.start, c.end, c.doc} || pos.Shared != c.shared {
+			t.Errorf("%s: got name, start, end, doc %v, shared %v, expected %v", Idents(ds[i])[0].Name, got, pos.Shared, c)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package goutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//Filter returns a sublist of Decls that match the predicate f.
+func (ds Decls) Filter(f func(ast.Decl) bool) (out Decls) {
+	for _, d := range ds {
+		if f(d) {
+			out = append(out, d)
+		}
+	}
+	return
+}
+
+//A Query is a compiled declaration query, created by ParseQuery.
+type Query struct {
+	src   string
+	terms []func(ast.Decl) bool
+}
+
+//String returns the source of the query.
+func (q *Query) String() string {
+	return q.src
+}
+
+//Match reports whether d satisfies every term of the query.
+func (q *Query) Match(d ast.Decl) bool {
+	for _, t := range q.terms {
+		if !t(d) {
+			return false
+		}
+	}
+	return true
+}
+
+//Query returns all Decls matching q.
+//
+//Queries on doc comments should be run on Decls that have been split
+//with SplitSpecs, so that each Decl has its own doc comment.
+func (ds Decls) Query(q *Query) Decls {
+	return ds.Filter(q.Match)
+}
+
+//ParseQuery parses a query for declarations, such as
+//	kind:func recv:*Package name:/^Parse/ exported:true doc:~cache
+//
+//A query is a list of terms separated by spaces. A declaration matches
+//the query if it matches every term. A term is a key and a value
+//separated by a colon, and may be prefixed by - to match only the
+//declarations that do not match the term.
+//
+//The keys are
+//	kind      one of func, method, type, var, or const. Every method is
+//	          also a func. Multiple kinds may be separated by commas.
+//	name      the declared name, matching if any name matches
+//	recv      the receiver type of a method, such as *Package. If the
+//	          value does not start with *, both pointer and value
+//	          receivers match.
+//	exported  true or false
+//	doc       the text of the doc comment
+//
+//The value of name, recv, and doc may be
+//	/regexp/  an RE2 regular expression
+//	~text     a case-insensitive substring
+//	glob      a shell glob, if it contains any of *?[
+//	text      exactly the text
+//Values containing spaces may be written as Go quoted strings, as in
+//doc:~"build tags".
+func ParseQuery(src string) (*Query, error) {
+	q := &Query{src: src}
+	s := strings.TrimSpace(src)
+	for s != "" {
+		neg := false
+		if s[0] == '-' {
+			neg = true
+			s = s[1:]
+		}
+
+		colon := strings.IndexByte(s, ':')
+		if colon <= 0 {
+			return nil, fmt.Errorf("Query term missing key in %s", s)
+		}
+		key := s[:colon]
+		s = s[colon+1:]
+
+		val, rest, err := queryValue(s)
+		if err != nil {
+			return nil, err
+		}
+		s = strings.TrimLeftFunc(rest, unicode.IsSpace)
+
+		t, err := queryTerm(key, val)
+		if err != nil {
+			return nil, err
+		}
+		if neg {
+			t = negterm(t)
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+func negterm(t func(ast.Decl) bool) func(ast.Decl) bool {
+	return func(d ast.Decl) bool {
+		return !t(d)
+	}
+}
+
+//queryValue splits the value of a term from the rest of the query.
+//A value is kept in its original form, except that quotes are removed,
+//so that queryMatcher can tell its kind.
+func queryValue(s string) (val, rest string, err error) {
+	prefix := ""
+	if strings.HasPrefix(s, "~") {
+		prefix, s = "~", s[1:]
+	}
+
+	switch {
+	case strings.HasPrefix(s, `"`):
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", fmt.Errorf("Invalid quoted value %s", s)
+		}
+		val, _ = strconv.Unquote(q)
+		if prefix == "" {
+			//keep quoted values from being mistaken for globs or regexps
+			prefix = "="
+		}
+		return prefix + val, s[len(q):], nil
+
+	case strings.HasPrefix(s, "/"):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '/':
+				return prefix + s[:i+1], s[i+1:], nil
+			}
+		}
+		return "", "", fmt.Errorf("Unterminated regexp %s", s)
+	}
+
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end < 0 {
+		end = len(s)
+	}
+	return prefix + s[:end], s[end:], nil
+}
+
+//queryMatcher creates the StringMatcher for the value of a term.
+func queryMatcher(val string) (StringMatcher, error) {
+	switch {
+	case strings.HasPrefix(val, "="):
+		return Exact(val[1:]), nil
+	case strings.HasPrefix(val, "~"):
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(val[1:])), nil
+	case len(val) >= 2 && strings.HasPrefix(val, "/"):
+		return regexp.Compile(val[1 : len(val)-1])
+	case strings.ContainsAny(val, "*?["):
+		return Glob(val)
+	}
+	return Exact(val), nil
+}
+
+func queryTerm(key, val string) (func(ast.Decl) bool, error) {
+	switch key {
+	case "kind":
+		kinds := Exact(strings.Split(val, ",")...)
+		for k := range kinds {
+			switch k {
+			case "func", "method", "type", "var", "const":
+			default:
+				return nil, fmt.Errorf("Unknown kind %s", k)
+			}
+		}
+		return func(d ast.Decl) bool {
+			switch dt := d.(type) {
+			case *ast.FuncDecl:
+				return kinds["func"] || (kinds["method"] && dt.Recv != nil)
+			case *ast.GenDecl:
+				return kinds[dt.Tok.String()]
+			}
+			return false
+		}, nil
+
+	case "exported":
+		want, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for exported: %s", val)
+		}
+		return func(d ast.Decl) bool {
+			for _, id := range Idents(d) {
+				if id.IsExported() {
+					return want
+				}
+			}
+			return !want
+		}, nil
+	}
+
+	//the remaining keys all take matchers, and a pointer receiver
+	//must not be taken for a glob.
+	ptr := false
+	if key == "recv" {
+		if strings.HasPrefix(val, "*") {
+			ptr, val = true, val[1:]
+		} else if strings.HasPrefix(val, "=*") {
+			ptr, val = true, "="+val[2:]
+		}
+	}
+	m, err := queryMatcher(val)
+	if err != nil {
+		return nil, err
+	}
+	switch key {
+	case "name":
+		return func(d ast.Decl) bool {
+			return len(Decls{d}.Named(m)) > 0
+		}, nil
+
+	case "recv":
+		return func(d ast.Decl) bool {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || len(fd.Recv.List) == 0 {
+				return false
+			}
+			var b bytes.Buffer
+			printer.Fprint(&b, token.NewFileSet(), fd.Recv.List[0].Type)
+			recv := b.String()
+			if ptr && !strings.HasPrefix(recv, "*") {
+				return false
+			}
+			return m.MatchString(strings.TrimPrefix(recv, "*"))
+		}, nil
+
+	case "doc":
+		return func(d ast.Decl) bool {
+			doc := DeclDoc(d)
+			return doc != nil && m.MatchString(doc.Text())
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown query key %s", key)
+}
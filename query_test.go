@@ -0,0 +1,72 @@
+package goutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const querySrc = `
+//ParseFoo parses the cache.
+func (p *Package) ParseFoo() {}
+
+//ParseBar parses.
+func (p Package) ParseBar() {}
+
+//Parse the Build Tags.
+func Parse() {}
+
+func parse() {}
+
+//T is a type.
+type T int
+
+const (
+	//A is in the cache.
+	A T = iota
+	b
+)
+`
+
+var queries = []struct {
+	q   string
+	out string
+}{
+	{"kind:func", "ParseFoo ParseBar Parse parse"},
+	{"kind:method", "ParseFoo ParseBar"},
+	{"kind:type,const", "T A b"},
+	{"recv:*Package", "ParseFoo"},
+	{"recv:Package", "ParseFoo ParseBar"},
+	{"kind:func recv:*Package name:/^Parse/ exported:true doc:~cache", "ParseFoo"},
+	{"exported:false", "parse b"},
+	{"name:Parse*", "ParseFoo ParseBar Parse"},
+	{"name:Parse", "Parse"},
+	{"-name:/^Parse/ kind:func", "parse"},
+	{`doc:~"build tags"`, "Parse"},
+	{`doc:~cache -kind:func`, "A"},
+}
+
+func TestQuery(t *testing.T) {
+	ds := parseDecls(t, querySrc).SplitSpecs()
+	for i, tc := range queries {
+		q, err := ParseQuery(tc.q)
+		if err != nil {
+			t.Errorf("%d: %s", i, err)
+			continue
+		}
+		var acc []string
+		for _, d := range ds.Query(q) {
+			acc = append(acc, Idents(d)[0].Name)
+		}
+		if out := strings.Join(acc, " "); out != tc.out {
+			t.Errorf("%d: %s: got %q expected %q", i, tc.q, out, tc.out)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, q := range []string{"name", ":x", "kind:struct", "exported:maybe", "name:/x", `doc:"x`, "size:1"} {
+		if _, err := ParseQuery(q); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+//Readme writes a README.md for a Go package, or set of Go packages,
+//from the package documentation.
+//
+//The packages may be specified as with the go(1) tool, including the
+//special ... operator. Each README.md is written to its package's
+//directory, or, with -n, printed instead.
+//
+//The README has a title, badges, installation instructions, the
+//synopsis and documentation of the package, and its BUG notes.
+//It is produced with a text/template, which may be customized with
+//-template, or by a .README.template.md file in the package's directory.
+//A template may define any of the blocks of the default template
+//	title badges install overview bugs footer
+//to replace just that block, or have text outside of any definition
+//to replace the whole template. The template is executed with
+//	.Name        the package name, or the name of a command
+//	.ImportPath  the import path of the package
+//	.Command     whether the package is a command
+//	.Synopsis    the first sentence of the documentation
+//	.Doc         the documentation, in Markdown
+//	.Bugs        the BUG notes, in Markdown, each on a single line
+//	.Package     the *goutil.Package, with its documentation parsed
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/doc"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	show = flag.Bool("n", false, "print each README instead of writing it")
+	tmpl = flag.String("template", "", "execute the template in `file` instead of the default")
+	tags = gocli.TagsFlag("")
+)
+
+//the name of a template in a package directory.
+const localTemplate = ".README.template.md"
+
+const defaultTemplate = `{{block "title" .}}# {{.Name}}{{end}}
+{{block "badges" .}}[![Go Reference](https://pkg.go.dev/badge/{{.ImportPath}}.svg)](https://pkg.go.dev/{{.ImportPath}})
+{{end}}
+{{.Synopsis}}
+
+{{block "install" .}}Install:
+` + "```" + `shell
+{{if .Command}}go install {{.ImportPath}}@latest{{else}}go get {{.ImportPath}}{{end}}
+` + "```" + `
+{{end}}
+* * *
+{{block "overview" .}}{{.Doc}}{{end}}
+{{- block "bugs" .}}{{if .Bugs}}## Bugs
+
+{{range .Bugs}}- {{.}}
+{{end}}
+{{end}}{{end}}
+{{- block "footer" .}}* * *
+Generated from the package documentation by readme.
+{{end}}`
+
+//data is what the template is executed with.
+type data struct {
+	Name, ImportPath string
+	Command          bool
+	Synopsis         string
+	Doc              string
+	Bugs             []string
+	Package          *goutil.Package
+}
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+func markdown(text string) (string, error) {
+	var b bytes.Buffer
+	if err := goutil.Markdown(&b, goutil.DocParse(text)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+//parse returns the default template overridden by the template in file,
+//if it is not "".
+func parse(file string) (*template.Template, error) {
+	t, err := template.New("README").Parse(defaultTemplate)
+	if err != nil || file == "" {
+		return t, err
+	}
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return t.Parse(string(src))
+}
+
+//readme returns the README of p.
+func readme(p *goutil.Package) ([]byte, error) {
+	if err := p.ParseDocs(0); err != nil {
+		return nil, err
+	}
+
+	file := *tmpl
+	if file == "" {
+		local := filepath.Join(p.Build.Dir, localTemplate)
+		if _, err := os.Stat(local); err == nil {
+			file = local
+		}
+	}
+	t, err := parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d := data{
+		Name:       p.Build.Name,
+		ImportPath: p.Build.ImportPath,
+		Command:    p.Build.IsCommand(),
+		Synopsis:   doc.Synopsis(p.Doc.Doc),
+		Package:    p,
+	}
+	if d.Command {
+		d.Name = filepath.Base(p.Build.Dir)
+	}
+	if d.Doc, err = markdown(p.Doc.Doc); err != nil {
+		return nil, err
+	}
+	for _, n := range p.Doc.Notes["BUG"] {
+		bug, err := markdown(strings.Join(strings.Fields(n.Body), " "))
+		if err != nil {
+			return nil, err
+		}
+		d.Bugs = append(d.Bugs, strings.TrimSpace(bug))
+	}
+
+	var b bytes.Buffer
+	if err = t.Execute(&b, d); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+
+	for _, p := range gocli.Flatten(pss) {
+		out, err := readme(p)
+		if err != nil {
+			fatal(p.Build.ImportPath+":", err)
+		}
+		if *show {
+			fmt.Printf("%s", out)
+			continue
+		}
+		if err = ioutil.WriteFile(filepath.Join(p.Build.Dir, "README.md"), out, 0666); err != nil {
+			fatal(err)
+		}
+	}
+}
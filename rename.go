@@ -0,0 +1,355 @@
+package goutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+//Lookup returns the object declared in p by name, which is either the
+//name of a package level declaration, or the name of a type and the name
+//of one of its methods or fields separated by a dot, as in Package.Parse.
+//
+//Lookup calls TypeCheck.
+func (p *Package) Lookup(name string) (types.Object, error) {
+	if err := p.TypeCheck(); p.Types == nil {
+		return nil, err
+	}
+	tname, member := name, ""
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		tname, member = name[:i], name[i+1:]
+	}
+
+	obj := p.Types.Scope().Lookup(tname)
+	if obj == nil {
+		return nil, fmt.Errorf("No %s in %s", tname, p.Build.ImportPath)
+	}
+	if member == "" {
+		return obj, nil
+	}
+
+	if _, ok := obj.(*types.TypeName); !ok {
+		return nil, fmt.Errorf("%s is not a type", tname)
+	}
+	m, idx, _ := types.LookupFieldOrMethod(obj.Type(), true, p.Types, member)
+	if m == nil || len(idx) != 1 {
+		//promoted members are declared by another type
+		return nil, fmt.Errorf("%s has no field or method %s", tname, member)
+	}
+	return m, nil
+}
+
+//A renaming is the state of a single Rename.
+type renaming struct {
+	ix       *Index
+	pkg      *types.Package
+	from, to string
+	//the objects renamed, which includes the fields
+	//embedding a renamed type.
+	objs map[types.Object]bool
+	refs []Ref
+	//identifiers that are the selector of a selector expression
+	//or qualified identifier.
+	qualified map[*ast.Ident]bool
+}
+
+//Rename returns an Editor that renames obj, and every reference to it in
+//the Index, to name. The files are not changed until the Editor is written.
+//
+//The obj may be a package level declaration, a method, or a field, and
+//must be declared in one of the Packages of the Index. Renaming a type
+//also renames the fields that embed it, which cannot be renamed directly.
+//
+//Rename returns an error, and no Editor, if the renaming could change the
+//meaning of the program or stop it from compiling:
+//	a package level name conflicting with another in its package,
+//	or with an import in a file referring to it
+//	a reference that would be captured by a local declaration of name
+//	a predeclared identifier, such as len, used by the package
+//	a field or method conflicting with, or hiding or being hidden by,
+//	another field or method of a type that has it, including by embedding
+//	a method required for a type to implement an interface, or a method of
+//	an interface that a type implements
+//	an exported name made unexported while referred to by other packages
+//
+//Only the Packages of the Index, and their test files, are checked and
+//updated, so an exported name should be renamed with an Index over every
+//Package using it.
+//Methods called only through interfaces outside the Index, and uses
+//by reflection, cannot be checked.
+//
+//If the doc comment of a package level declaration or method begins with
+//its name, as is conventional, the name in the comment is also updated.
+func (ix *Index) Rename(obj types.Object, name string) (*Editor, error) {
+	obj = origin(obj)
+	if !token.IsIdentifier(name) || name == "_" {
+		return nil, fmt.Errorf("Invalid name %s", name)
+	}
+	if obj.Name() == name {
+		return nil, fmt.Errorf("%s is already named %s", obj.Name(), name)
+	}
+
+	p := ix.pkg(obj.Pkg())
+	if p == nil {
+		return nil, fmt.Errorf("%s is not declared in the indexed packages", obj.Name())
+	}
+
+	field := false
+	switch o := obj.(type) {
+	case *types.Var:
+		field = o.IsField()
+		if o.Embedded() {
+			return nil, fmt.Errorf("Cannot rename embedded field %s: rename its type", o.Name())
+		}
+	case *types.PkgName, *types.Label:
+		return nil, fmt.Errorf("Cannot rename %s", obj.Name())
+	}
+	if !field && !toplevel(obj) {
+		return nil, fmt.Errorf("%s is not a package level declaration, method, or field", obj.Name())
+	}
+	if f, ok := obj.(*types.Func); ok && f.Type().(*types.Signature).Recv() == nil {
+		if f.Name() == "init" || (f.Name() == "main" && p.Build.Name == "main") {
+			return nil, fmt.Errorf("Cannot rename %s", f.Name())
+		}
+	}
+
+	r := &renaming{
+		ix:        ix,
+		pkg:       obj.Pkg(),
+		from:      obj.Name(),
+		to:        name,
+		objs:      map[types.Object]bool{obj: true},
+		qualified: map[*ast.Ident]bool{},
+	}
+	r.collect()
+
+	if token.IsExported(r.from) && !token.IsExported(name) {
+		for _, ref := range r.refs {
+			if ref.Package != p || ref.unit.external {
+				return nil, fmt.Errorf("%s is used by %s at %s", r.from, ref.Package.Build.ImportPath, ref.Position())
+			}
+		}
+	}
+
+	if !field && obj.Parent() == obj.Pkg().Scope() {
+		if err := r.checkPackageLevel(p); err != nil {
+			return nil, err
+		}
+	}
+	//a renamed type may be embedded as a field.
+	if field || len(r.objs) > 1 || obj.Parent() != obj.Pkg().Scope() {
+		if err := r.checkMembers(); err != nil {
+			return nil, err
+		}
+	}
+
+	e := p.Edit()
+	seen := map[token.Position]bool{}
+	for _, ref := range r.refs {
+		pos := ref.Position()
+		if seen[pos] {
+			continue
+		}
+		seen[pos] = true
+		ref.Package.editIdent(e, ref.Ident, name)
+	}
+	r.renameDoc(p, e, obj)
+	return e, nil
+}
+
+//pkg returns the Package of the Index that was type checked as tp.
+func (ix *Index) pkg(tp *types.Package) *Package {
+	for _, p := range ix.Packages {
+		if p.Types != nil && p.Types == tp {
+			return p
+		}
+	}
+	return nil
+}
+
+//editIdent replaces id, which must be from p, with name.
+func (p *Package) editIdent(e *Editor, id *ast.Ident, name string) {
+	pos := p.FileSet.Position(id.Pos())
+	e.addIn(p, pos.Filename, pos.Offset, pos.Offset+len(id.Name), []byte(name))
+}
+
+//collect finds every identifier declaring or referring to the objects
+//being renamed, adding the fields that embed a renamed type as it goes.
+func (r *renaming) collect() {
+	for _, u := range r.ix.units {
+		//an embedded field's identifier both defines the field
+		//and uses the type.
+		for id, obj := range u.info.Defs {
+			if v, ok := obj.(*types.Var); ok && v.Embedded() && u.info.Uses[id] != nil && r.objs[u.object(u.info.Uses[id])] {
+				r.objs[u.object(v)] = true
+			}
+		}
+	}
+	for _, u := range r.ix.units {
+		for _, f := range u.files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if s, ok := n.(*ast.SelectorExpr); ok {
+					r.qualified[s.Sel] = true
+				}
+				id, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := u.info.Defs[id]
+				if obj == nil {
+					obj = u.info.Uses[id]
+				}
+				if obj != nil && r.objs[u.object(obj)] {
+					r.refs = append(r.refs, Ref{u.p, id, u.test, u})
+				}
+				return true
+			})
+		}
+	}
+}
+
+//inPackage returns the units of the Index that are in the package of p:
+//p itself, and p with its test files.
+func (r *renaming) inPackage(p *Package) (us []*unit) {
+	for _, u := range r.ix.units {
+		if u.p == p && !u.external {
+			us = append(us, u)
+		}
+	}
+	return
+}
+
+//checkPackageLevel checks renaming a package level declaration of p.
+func (r *renaming) checkPackageLevel(p *Package) error {
+	//the test files of p may declare the name too.
+	for _, u := range r.inPackage(p) {
+		if c := u.types.Scope().Lookup(r.to); c != nil {
+			return fmt.Errorf("%s conflicts with %s at %s", r.to, c.Name(), p.FileSet.Position(c.Pos()))
+		}
+	}
+
+	//every unqualified reference must still resolve to the renamed object.
+	for _, ref := range r.refs {
+		if r.qualified[ref.Ident] {
+			continue
+		}
+		scope := ref.unit.types.Scope().Innermost(ref.Ident.Pos())
+		if scope == nil {
+			continue
+		}
+		if _, c := scope.LookupParent(r.to, ref.Ident.Pos()); c != nil && c.Parent() != types.Universe {
+			return fmt.Errorf("%s at %s would refer to %s declared at %s", r.from, ref.Position(), r.to, ref.Package.FileSet.Position(c.Pos()))
+		}
+	}
+
+	//and no reference to a predeclared identifier may resolve to it.
+	if types.Universe.Lookup(r.to) != nil {
+		for _, u := range r.inPackage(p) {
+			for id, obj := range u.info.Uses {
+				if obj.Parent() == types.Universe && obj.Name() == r.to {
+					return fmt.Errorf("%s would hide the predeclared %s used at %s", r.from, r.to, p.FileSet.Position(id.Pos()))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//A namedType is a named type declared in the package of a unit.
+type namedType struct {
+	*types.TypeName
+	unit *unit
+}
+
+//checkMembers checks renaming a field or method.
+func (r *renaming) checkMembers() error {
+	var named, ifaces []namedType
+	for _, u := range r.ix.units {
+		scope := u.types.Scope()
+		for _, nm := range scope.Names() {
+			if tn, ok := scope.Lookup(nm).(*types.TypeName); ok && !tn.IsAlias() {
+				named = append(named, namedType{tn, u})
+				if types.IsInterface(tn.Type()) {
+					ifaces = append(ifaces, namedType{tn, u})
+				}
+			}
+		}
+	}
+
+	for _, tn := range named {
+		t := tn.Type()
+		//the unexported names of the package checked with its tests
+		//are those of its own copy of the package.
+		pkg := r.pkg
+		if tn.unit.decls != nil {
+			pkg = tn.unit.types
+		}
+		old, _, _ := types.LookupFieldOrMethod(t, true, pkg, r.from)
+		if old == nil || !r.objs[tn.unit.object(old)] {
+			continue
+		}
+		if c, _, _ := types.LookupFieldOrMethod(t, true, pkg, r.to); c != nil {
+			return fmt.Errorf("%s.%s conflicts with %s.%s", tn.Name(), r.from, tn.Name(), r.to)
+		}
+
+		if _, ok := old.(*types.Func); !ok {
+			continue
+		}
+		if iface, ok := t.Underlying().(*types.Interface); ok {
+			//every type implementing the interface would need renaming too.
+			for _, impl := range named {
+				it := impl.Type()
+				if types.IsInterface(it) {
+					continue
+				}
+				if types.Implements(it, iface) || types.Implements(types.NewPointer(it), iface) {
+					return fmt.Errorf("%s implements %s, so %s.%s cannot be renamed", impl.Name(), tn.Name(), tn.Name(), r.from)
+				}
+			}
+			continue
+		}
+		for _, in := range ifaces {
+			iface := in.Type().Underlying().(*types.Interface)
+			if m, _, _ := types.LookupFieldOrMethod(iface, false, pkg, r.from); m == nil {
+				continue
+			}
+			if types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface) {
+				return fmt.Errorf("%s.%s is needed to implement %s", tn.Name(), r.from, in.Name())
+			}
+		}
+	}
+	return nil
+}
+
+//renameDoc updates the name at the start of the doc comment of obj.
+func (r *renaming) renameDoc(p *Package, e *Editor, obj types.Object) {
+	if v, ok := obj.(*types.Var); ok && v.IsField() {
+		return
+	}
+	for _, d := range p.Decls().SplitSpecs() {
+		for _, id := range Idents(d) {
+			if p.Info.Defs[id] != obj {
+				continue
+			}
+			doc := DeclDoc(d)
+			if doc == nil || len(doc.List) == 0 {
+				return
+			}
+			c := doc.List[0]
+			text := strings.TrimPrefix(c.Text, "//")
+			if len(text) == len(c.Text) {
+				return
+			}
+			trimmed := strings.TrimLeft(text, " \t")
+			if !strings.HasPrefix(trimmed, r.from+" ") {
+				return
+			}
+			pos := p.FileSet.Position(c.Pos())
+			start := pos.Offset + 2 + len(text) - len(trimmed)
+			e.addIn(p, pos.Filename, start, start+len(r.from), []byte(r.to))
+			return
+		}
+	}
+}
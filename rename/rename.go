@@ -0,0 +1,98 @@
+//Rename renames a package level declaration, method, or field of a Go
+//package, with the standard build tags, and every reference to it.
+//
+//The declaration is named as in the package's documentation, such as
+//	rename github.com/jimmyfrasche/goutil Package.Parse ParseFiles
+//
+//References are updated in the package itself and in any packages listed
+//after the new name, which may be specified as with the go(1) tool,
+//including the special ... operator. To rename an exported name,
+//list every package that uses it.
+//
+//The rename is refused if it could stop the packages from compiling or
+//change what they mean, such as when the new name conflicts with another
+//declaration or would be shadowed where the old name is used.
+//
+//By default, rename prints a unified diff of the changes.
+//With -w, it writes the changed files instead.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	write = flag.Bool("w", false, "write the changed files instead of printing a diff")
+	tags  = gocli.TagsFlag("")
+)
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] package name newname [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+//Usage: %name %flags package name newname [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 3 {
+		Usage()
+		os.Exit(2)
+	}
+	ctx := goutil.Context(*tags...)
+
+	p, err := goutil.Import(ctx, args[0])
+	if err != nil {
+		fatal(err)
+	}
+	pkgs := goutil.Packages{p}
+	if len(args) > 3 {
+		pss, err := gocli.FirstError(gocli.Import(false, ctx, args[3:]))
+		if err != nil {
+			fatal(err)
+		}
+		pkgs = append(pkgs, gocli.Flatten(pss)...).Uniq()
+	}
+
+	if err = pkgs.Parse(true); err != nil {
+		fatal(err)
+	}
+	ix, err := pkgs.Index()
+	if err != nil {
+		//renaming code that does not type check is not safe.
+		fatal(err)
+	}
+
+	obj, err := p.Lookup(args[1])
+	if err != nil {
+		fatal(err)
+	}
+	e, err := ix.Rename(obj, args[2])
+	if err != nil {
+		fatal(err)
+	}
+
+	if *write {
+		if err = e.Write(); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	diff, err := e.Diff()
+	if err != nil {
+		fatal(err)
+	}
+	os.Stdout.Write(diff)
+}
@@ -0,0 +1,154 @@
+package goutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const renameSrc = `package a
+
+import "io"
+
+// T is a type.
+type T struct {
+	F int
+	io.Reader
+}
+
+// M is a method.
+func (t *T) M() int { return t.F }
+
+type E struct {
+	T
+	G int
+}
+
+type I interface {
+	N()
+}
+
+type C struct{}
+
+func (C) N() {}
+
+var V = T{F: 1}
+
+func f(e E) int {
+	x := 1
+	_ = len("")
+	return e.T.M() + e.F + V.F + x
+}
+`
+
+func TestRename(t *testing.T) {
+	table := []struct {
+		from, to string
+		//lines expected in the diff, or an error message
+		diff []string
+		err  string
+	}{
+		{"T", "S", []string{"-// T is a type.", "+// S is a type.", "+type S struct {", "+func (t *S) M() int { return t.F }", "+	S", "+var V = S{F: 1}", "+	return e.S.M() + e.F + V.F + x"}, ""},
+		{"T.F", "H", []string{"+	H int", "+func (t *T) M() int { return t.H }", "+var V = T{H: 1}", "+	return e.T.M() + e.H + V.H + x"}, ""},
+		{"T.M", "P", []string{"+// P is a method.", "+	return e.T.P() + e.F + V.F + x"}, ""},
+		{"V", "x", nil, "would refer to x"},
+		{"V", "T", nil, "conflicts with T"},
+		{"V", "io", nil, "would refer to io"},
+		{"V", "len", nil, "predeclared len"},
+		{"T.F", "G", nil, "E.F conflicts with E.G"},
+		{"T.F", "Reader", nil, "conflicts with E.Reader"},
+		{"C.N", "O", nil, "needed to implement I"},
+		{"I.N", "O", nil, "C implements I"},
+		{"T.Reader", "R", nil, "embedded field"},
+		{"V", "1", nil, "Invalid name"},
+	}
+	for _, c := range table {
+		p := importFile(t, renameSrc)
+		ix, err := Packages{p}.Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj, err := p.Lookup(c.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := ix.Rename(obj, c.to)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s → %s: expected error %q, got %v", c.from, c.to, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s → %s: %s", c.from, c.to, err)
+			continue
+		}
+		diff, err := e.Diff()
+		if err != nil {
+			t.Errorf("%s → %s: %s", c.from, c.to, err)
+			continue
+		}
+		lines := Exact(strings.Split(string(diff), "\n")...)
+		for _, l := range c.diff {
+			if !lines[l] {
+				t.Errorf("%s → %s: diff missing %q:\n%s", c.from, c.to, l, diff)
+			}
+		}
+	}
+}
+
+func TestRenameTestFiles(t *testing.T) {
+	const testSrc = `package a
+
+import "testing"
+
+func TestT(t *testing.T) {
+	var x T
+	_ = x.M() + V.F
+}
+
+var S = 2
+`
+	table := []struct {
+		from, to string
+		diff     []string
+		err      string
+	}{
+		{"T", "U", []string{"+type U struct {", "+	var x U"}, ""},
+		{"T.M", "P", []string{"+	_ = x.P() + V.F"}, ""},
+		{"V", "W", []string{"+var W = T{F: 1}", "+	_ = x.M() + W.F"}, ""},
+		{"T", "S", nil, "conflicts with S"},
+	}
+	for _, c := range table {
+		p := importFiles(t, map[string]string{"a.go": renameSrc, "a_test.go": testSrc})
+		ix, err := Packages{p}.Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj, err := p.Lookup(c.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := ix.Rename(obj, c.to)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s → %s: expected error %q, got %v", c.from, c.to, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s → %s: %s", c.from, c.to, err)
+			continue
+		}
+		diff, err := e.Diff()
+		if err != nil {
+			t.Errorf("%s → %s: %s", c.from, c.to, err)
+			continue
+		}
+		lines := Exact(strings.Split(string(diff), "\n")...)
+		for _, l := range c.diff {
+			if !lines[l] {
+				t.Errorf("%s → %s: diff missing %q:\n%s", c.from, c.to, l, diff)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package goutil
+
+import (
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+)
+
+//file returns the contents of the named file, reading it only once.
+func (p *Package) file(name string) ([]byte, error) {
+	if src, ok := p.src[name]; ok {
+		return src, nil
+	}
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if p.src == nil {
+		p.src = map[string][]byte{}
+	}
+	p.src[name] = src
+	return src, nil
+}
+
+//Source returns the verbatim source text of d, which must be from p,
+//as read from its file. If doc is true, the text begins with the doc
+//comment of d, if it has one.
+//
+//For a GenDecl split by SplitSpecs from a parenthesized block,
+//this is only the text of its spec. For one of several names split
+//from a single spec, it is the text of the whole spec,
+//as described by DeclPosition.Shared.
+//
+//It is up to the caller to call Parse before invoking this method,
+//and, to include doc comments, to have told Parse to parse comments.
+func (p *Package) Source(d ast.Decl, doc bool) ([]byte, error) {
+	pos := p.Position(d)
+	src, err := p.file(pos.Start.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := pos.Start.Offset, pos.End.Offset
+	if doc {
+		start = pos.Doc.Offset
+	}
+	if start < 0 || end > len(src) || start > end {
+		return nil, fmt.Errorf("%s changed since it was parsed", pos.Start.Filename)
+	}
+	return src[start:end], nil
+}
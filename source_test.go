@@ -0,0 +1,38 @@
+package goutil
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	p := importFile(t, positionSrc)
+	ds := p.Decls().SplitSpecs()
+	table := []struct {
+		name      string
+		src, docs string
+	}{
+		{"F", "func F() {}", "//F is a func.\nfunc F() {}"},
+		{"A", "A = 1", "//A is a var.\n\tA = 1"},
+		{"B", "B int", "B int"},
+		{"D", "var C, D = 1, 2", "var C, D = 1, 2"},
+		{"G", "E, G = 3, 4", "//E and G are vars.\n\tE, G = 3, 4"},
+	}
+	for _, c := range table {
+		d := ds.Named(Exact(c.name))
+		if len(d) != 1 {
+			t.Fatalf("expected one declaration of %s", c.name)
+		}
+		for _, doc := range []bool{false, true} {
+			want := c.src
+			if doc {
+				want = c.docs
+			}
+			src, err := p.Source(d[0], doc)
+			if err != nil {
+				t.Errorf("%s: %s", c.name, err)
+			} else if string(src) != want {
+				t.Errorf("%s, doc %v: got %q expected %q", c.name, doc, src, want)
+			}
+		}
+	}
+}
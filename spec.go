@@ -0,0 +1,162 @@
+package goutil
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//A Spec is a single entry split out of an *ast.GenDecl, along with
+//the context needed to make sense of it on its own.
+type Spec struct {
+	//Decl is the GenDecl the entry was split from.
+	Decl *ast.GenDecl
+	//Spec is the *ast.TypeSpec or *ast.ValueSpec the entry was split from.
+	Spec ast.Spec
+	//Iota is the index of Spec in Decl.Specs, which is the value of iota
+	//for a const.
+	Iota int
+
+	//Names declared by this entry. There is more than one name
+	//only for tuple assignments, such as
+	//	var a, b, c = f()
+	//which cannot be split.
+	Names []*ast.Ident
+	//Type and Values of the entry, if it is from a ValueSpec.
+	//For a tuple assignment, Values is the single expression assigned.
+	Type   ast.Expr
+	Values []ast.Expr
+	//Implicit is set when a const has no type or values of its own
+	//and Type and Values are inherited from the preceding const in
+	//its block. Combined with Iota this is enough to recover its value.
+	Implicit bool
+
+	//Doc is the Spec's doc comment, or, if the Spec is the only one in
+	//Decl, Decl's doc comment.
+	Doc *ast.CommentGroup
+	//Comment is the Spec's line comment.
+	Comment *ast.CommentGroup
+}
+
+//Specs returns a Spec for every entry in every *ast.GenDecl in ds.
+//*ast.FuncDecl are ignored.
+//
+//ValueSpecs declaring multiple names are split into one Spec per name,
+//unless they are tuple assignments.
+func (ds Decls) Specs() (out []*Spec) {
+	for _, d := range ds {
+		if g, ok := d.(*ast.GenDecl); ok {
+			out = append(out, specsOf(g)...)
+		}
+	}
+	return
+}
+
+func specsOf(g *ast.GenDecl) (out []*Spec) {
+	//the type and values implicitly repeated in a const block
+	var typ ast.Expr
+	var values []ast.Expr
+
+	for i, s := range g.Specs {
+		var doc *ast.CommentGroup
+		if len(g.Specs) == 1 {
+			doc = g.Doc
+		}
+
+		switch st := s.(type) {
+		case *ast.TypeSpec:
+			if st.Doc != nil {
+				doc = st.Doc
+			}
+			out = append(out, &Spec{
+				Decl:    g,
+				Spec:    s,
+				Iota:    i,
+				Names:   []*ast.Ident{st.Name},
+				Doc:     doc,
+				Comment: st.Comment,
+			})
+
+		case *ast.ValueSpec:
+			if st.Doc != nil {
+				doc = st.Doc
+			}
+			implicit := false
+			if g.Tok == token.CONST {
+				if st.Type != nil || len(st.Values) > 0 {
+					typ, values = st.Type, st.Values
+				} else {
+					implicit = true
+				}
+			} else {
+				typ, values = st.Type, st.Values
+			}
+
+			mk := func(names []*ast.Ident, values []ast.Expr) *Spec {
+				return &Spec{
+					Decl:     g,
+					Spec:     s,
+					Iota:     i,
+					Names:    names,
+					Type:     typ,
+					Values:   values,
+					Implicit: implicit,
+					Doc:      doc,
+					Comment:  st.Comment,
+				}
+			}
+
+			switch {
+			case len(st.Names) == 1 || (len(values) != 0 && len(values) != len(st.Names)):
+				//a single name or a tuple assignment
+				out = append(out, mk(st.Names, values))
+			case len(values) == 0:
+				for _, nm := range st.Names {
+					out = append(out, mk([]*ast.Ident{nm}, nil))
+				}
+			default:
+				for j, nm := range st.Names {
+					out = append(out, mk([]*ast.Ident{nm}, values[j:j+1]))
+				}
+			}
+		}
+	}
+	return
+}
+
+//GenDecl returns an *ast.GenDecl containing only this entry.
+//
+//The GenDecl is unparenthesized and its Doc is s.Doc. Its TokPos is
+//the position of the original token if the entry was not in a
+//parenthesized block, otherwise it is the position of the Spec,
+//so that the GenDecl's Pos and End are those of the entry.
+//
+//Implicit types and values are not filled in, as the
+//result would be misleading without the value of iota.
+func (s *Spec) GenDecl() *ast.GenDecl {
+	spec := s.Spec
+	if vs, ok := s.Spec.(*ast.ValueSpec); ok && len(s.Names) != len(vs.Names) {
+		var values []ast.Expr
+		if !s.Implicit {
+			values = s.Values
+		}
+		spec = &ast.ValueSpec{
+			Doc:     vs.Doc,
+			Names:   s.Names,
+			Type:    vs.Type,
+			Values:  values,
+			Comment: vs.Comment,
+		}
+	}
+
+	pos := s.Decl.TokPos
+	if s.Decl.Lparen.IsValid() {
+		pos = spec.Pos()
+	}
+
+	return &ast.GenDecl{
+		Doc:    s.Doc,
+		TokPos: pos,
+		Tok:    s.Decl.Tok,
+		Specs:  []ast.Spec{spec},
+	}
+}
@@ -0,0 +1,99 @@
+package goutil
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func parseDecls(t *testing.T, src string) Decls {
+	f, err := parser.ParseFile(token.NewFileSet(), "x.go", "package x\n"+src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Decls(f.Decls)
+}
+
+func names(ids []*ast.Ident) string {
+	var acc []string
+	for _, id := range ids {
+		acc = append(acc, id.Name)
+	}
+	return strings.Join(acc, ",")
+}
+
+var specs = []struct {
+	src      string
+	names    []string
+	iota     []int
+	implicit []bool
+	docs     []string
+}{
+	{
+		"//doc\nvar a, b, c = 1, 2, 3",
+		[]string{"a", "b", "c"},
+		[]int{0, 0, 0},
+		[]bool{false, false, false},
+		[]string{"doc\n", "doc\n", "doc\n"},
+	},
+	{
+		"var a, b int",
+		[]string{"a", "b"},
+		[]int{0, 0},
+		[]bool{false, false},
+		[]string{"", ""},
+	},
+	{
+		"var a, b, c = f()",
+		[]string{"a,b,c"},
+		[]int{0},
+		[]bool{false},
+		[]string{""},
+	},
+	{
+		"//group\nconst (\n//A\nA T = iota\nB\n//C\nC\n)",
+		[]string{"A", "B", "C"},
+		[]int{0, 1, 2},
+		[]bool{false, true, true},
+		[]string{"A\n", "", "C\n"},
+	},
+	{
+		"type (\n//T\nT int\nU int\n)",
+		[]string{"T", "U"},
+		[]int{0, 1},
+		[]bool{false, false},
+		[]string{"T\n", ""},
+	},
+}
+
+func TestSpecs(t *testing.T) {
+	for i, tc := range specs {
+		ss := parseDecls(t, tc.src).Specs()
+		if len(ss) != len(tc.names) {
+			t.Errorf("%d: got %d specs, expected %d", i, len(ss), len(tc.names))
+			continue
+		}
+		for j, s := range ss {
+			if nm := names(s.Names); nm != tc.names[j] {
+				t.Errorf("%d.%d: names %s ≠ %s", i, j, nm, tc.names[j])
+			}
+			if s.Iota != tc.iota[j] {
+				t.Errorf("%d.%d: iota %d ≠ %d", i, j, s.Iota, tc.iota[j])
+			}
+			if s.Implicit != tc.implicit[j] {
+				t.Errorf("%d.%d: implicit %v ≠ %v", i, j, s.Implicit, tc.implicit[j])
+			}
+			if doc := s.Doc.Text(); doc != tc.docs[j] {
+				t.Errorf("%d.%d: doc %q ≠ %q", i, j, doc, tc.docs[j])
+			}
+			if s.Implicit && s.Type == nil {
+				t.Errorf("%d.%d: implicit type not inherited", i, j)
+			}
+			if g := s.GenDecl(); g.Pos() != s.Names[0].Pos() && g.Pos() != s.Decl.TokPos {
+				t.Errorf("%d.%d: GenDecl has wrong position", i, j)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package goutil
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+//textWriter is a docWriter for plain text, as printed by go doc -all.
+type textWriter struct {
+	bytes.Buffer
+	indent, preIndent string
+	width             int
+	//title is set after the package clause is written, until the end
+	//of its line.
+	title bool
+	//indoc is set after a declaration, whose documentation is indented.
+	indoc bool
+}
+
+func (t *textWriter) endTitle() {
+	if t.title {
+		t.WriteString("\n\n")
+		t.title = false
+	}
+}
+
+func (t *textWriter) heading(level int, anchor, text string) {
+	switch {
+	case level == 1:
+		t.WriteString(t.indent + text)
+		t.title = true
+	case level == 2 && anchor != "pkg-index":
+		t.endTitle()
+		t.WriteString(t.indent + strings.ToUpper(text) + "\n\n")
+	}
+	//the declaration that follows any other heading identifies it.
+}
+
+func (t *textWriter) decl(src string) {
+	if t.title {
+		//the import comment of the package clause.
+		t.WriteString(" // " + src + "\n\n")
+		t.title = false
+		return
+	}
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(src, "\n")+"\n", "\n") {
+		if line != "" && line != "\n" {
+			t.WriteString(t.indent)
+		}
+		t.WriteString(line)
+	}
+	t.indoc = true
+}
+
+func (t *textWriter) doc(d *Doc) {
+	t.endTitle()
+	indent := t.indent
+	if t.indoc {
+		indent += "    "
+		t.indoc = false
+	}
+	t.blocks(d.Blocks, indent)
+	t.WriteString("\n")
+}
+
+func (t *textWriter) index(entries []indexEntry) {}
+
+//lineText returns the text of a line of a Para, with the URLs of any
+//links to LinkDefs.
+func lineText(b Block, i int) string {
+	line := strings.TrimSuffix(b.Lines[i], "\n")
+	if i >= len(b.Spans) || b.Spans[i] == nil {
+		return line
+	}
+	var s bytes.Buffer
+	for _, sp := range b.Spans[i] {
+		s.WriteString(sp.Text)
+		if sp.Kind == LinkSpan && sp.Doc == nil && sp.URL != sp.Text {
+			s.WriteString(" <" + sp.URL + ">")
+		}
+	}
+	return s.String()
+}
+
+//wrap writes the words of the Para b wrapped to the width, less margin,
+//the first line prefixed by first and the rest by indent.
+func (t *textWriter) wrap(b Block, first, indent string, margin int) {
+	n := 0
+	prefix := first
+	for i := range b.Lines {
+		for _, f := range strings.Fields(lineText(b, i)) {
+			w := utf8.RuneCountInString(f)
+			if n > 0 && margin+n+1+w > t.width {
+				t.WriteString("\n")
+				n = 0
+			}
+			if n == 0 {
+				t.WriteString(prefix)
+				prefix = indent
+			} else {
+				t.WriteString(" ")
+				n++
+			}
+			t.WriteString(f)
+			n += w
+		}
+	}
+	if n > 0 {
+		t.WriteString("\n")
+	}
+}
+
+//blocks writes bs, each line prefixed by indent, which counts against the
+//width beyond the indent of t, as for the documentation of a declaration.
+func (t *textWriter) blocks(bs []Block, indent string) {
+	extra := utf8.RuneCountInString(indent) - utf8.RuneCountInString(t.indent)
+	for i, b := range bs {
+		if i > 0 {
+			t.WriteString("\n")
+		}
+		switch b.Kind {
+		case Para:
+			t.wrap(b, indent, indent, extra)
+		case Head:
+			text := b.Lines[0]
+			t.WriteString(indent + text + "\n")
+			t.WriteString(indent + strings.Repeat("-", utf8.RuneCountInString(text)) + "\n")
+		case Pre:
+			for _, line := range b.Lines {
+				if line != "\n" {
+					t.WriteString(indent + t.preIndent)
+				}
+				t.WriteString(line)
+			}
+		case List:
+			for j, it := range b.Items {
+				if j > 0 && b.Spaced {
+					t.WriteString("\n")
+				}
+				marker := "  - "
+				if it.Number != "" {
+					marker = " " + it.Number + ". "
+				}
+				inner := indent + strings.Repeat(" ", utf8.RuneCountInString(marker))
+				margin := extra + utf8.RuneCountInString(marker)
+				for k, p := range it.Blocks {
+					if k == 0 {
+						t.wrap(p, indent+marker, inner, margin)
+					} else {
+						t.WriteString("\n")
+						t.wrap(p, inner, inner, margin)
+					}
+				}
+			}
+		}
+	}
+}
+
+//Text writes blocks to w as plain text, as go doc would print them.
+//
+//Each line is prefixed by indent, and each line of a Pre block is also
+//prefixed by preIndent. Blank lines are not prefixed. The text of a Para
+//or List is wrapped so that no line is longer than width, not counting
+//the indent, unless a single word is. Headings are underlined.
+//Links to documentation are written as their text, and other links as
+//their text followed by their URL in angle brackets.
+func Text(w io.Writer, blocks []Block, indent, preIndent string, width int) error {
+	t := &textWriter{indent: indent, preIndent: preIndent, width: width}
+	t.blocks(blocks, indent)
+	_, err := w.Write(t.Bytes())
+	return err
+}
+
+//Text writes the documentation of p to w as plain text, in the format
+//of go doc -all: the package clause and overview, then the constants,
+//variables, functions, and types, with the documentation of each
+//declaration indented under it.
+//
+//The indent, preIndent, and width are as for the Text function.
+//
+//It is up to the caller to call ParseDocs before invoking this method.
+func (p *Package) Text(w io.Writer, indent, preIndent string, width int) error {
+	t := &textWriter{indent: indent, preIndent: preIndent, width: width}
+	if err := p.renderDoc(t, nil); err != nil {
+		return err
+	}
+	t.endTitle()
+	_, err := w.Write(t.Bytes())
+	return err
+}
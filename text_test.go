@@ -0,0 +1,82 @@
+package goutil
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestText(t *testing.T) {
+	const text = `Package x does a great many things, see [the site].
+
+Usage
+
+The steps are:
+  1. first do this, and then that, and then the other
+  2. stop
+
+Code:
+
+	x := 1
+
+	y := 2
+
+[the site]: https://example.com
+`
+	const expected = `> Package x does a great many things,
+> see the site <https://example.com>.
+
+> Usage
+> -----
+
+> The steps are:
+
+>  1. first do this, and then that,
+>     and then the other
+>  2. stop
+
+> Code:
+
+> ..x := 1
+
+> ..y := 2
+`
+	var b bytes.Buffer
+	if err := Text(&b, DocParse(text), "> ", "..", 36); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestPackageTextWidth(t *testing.T) {
+	const src = `//Package a has documentation long enough to need wrapping at every level.
+package a
+
+//T is a type whose documentation is indented under its declaration, and so
+//must be wrapped shorter than the package documentation is.
+//  - a list in the documentation of the type is indented further still, and
+//    must be wrapped shorter again
+type T int
+
+//M is a method of T, whose documentation is indented just as that of T is,
+//under the declaration of the method.
+func (T) M() {}
+`
+	p := importFile(t, src)
+	if err := p.ParseDocs(0); err != nil {
+		t.Fatal(err)
+	}
+	const width = 40
+	var b bytes.Buffer
+	if err := p.Text(&b, "", "\t", width); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if utf8.RuneCountInString(line) > width {
+			t.Errorf("line longer than %d: %q\nin\n%s", width, line, b.String())
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package goutil
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+//importer satisfies types.ImporterFrom by importing and type checking
+//packages with goutil, so that every type checked Package shares
+//its *types.Package with the packages that import it.
+type importer struct {
+	ctx *build.Context
+}
+
+func (im importer) Import(path string) (*types.Package, error) {
+	return im.ImportFrom(path, "", 0)
+}
+
+func (im importer) ImportFrom(path, dir string, _ types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	if path == "C" {
+		return nil, errors.New("Cannot import C")
+	}
+
+	//resolve the directory first so that vendored imports work.
+	bp, err := im.ctx.Import(path, dir, build.FindOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Import(im.ctx, bp.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	//errors in dependencies are tolerated so long as go/types
+	//could make something out of them.
+	if err = p.TypeCheck(); p.Types == nil {
+		return nil, err
+	}
+	return p.Types, nil
+}
+
+//astFiles returns the files of p.AST sorted by filename,
+//as map order would make the type checker nondeterministic.
+func (p *Package) astFiles() (files []*ast.File) {
+	var names []string
+	for name := range p.AST.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		files = append(files, p.AST.Files[name])
+	}
+	return
+}
+
+//TypeCheck type checks the package with go/types and sets p.Types and p.Info.
+//
+//If p.AST is nil, TypeCheck calls Parse(false). Imports are resolved with
+//Import, using the Package's Context, and are type checked in turn, so
+//types.Objects from different Packages imported with the same Context
+//may be compared directly.
+//
+//If there are type errors, the first is returned, but p.Types and p.Info
+//are still set, as go/types records as much information as it can.
+func (p *Package) TypeCheck() error {
+	if p.Types != nil {
+		return p.typeErr
+	}
+	if p.checking {
+		return fmt.Errorf("Import cycle through %s", p.Build.ImportPath)
+	}
+	if err := p.Parse(false); err != nil {
+		return err
+	}
+
+	p.checking = true
+	defer func() {
+		p.checking = false
+	}()
+
+	p.Types, p.Info, p.typeErr = p.check(p.Build.ImportPath, p.astFiles())
+	return p.typeErr
+}
+
+//check type checks files, parsed into p.FileSet, as the package path,
+//returning the first error, if any, along with what could be checked.
+func (p *Package) check(path string, files []*ast.File) (*types.Package, *types.Info, error) {
+	var first error
+	conf := &types.Config{
+		Importer:    importer{p.Context},
+		FakeImportC: true,
+		Sizes:       types.SizesFor("gc", p.Context.GOARCH),
+		Error: func(err error) {
+			if first == nil {
+				first = err
+			}
+		},
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+
+	//with Error set, Check only reports the first error, which we already have.
+	pkg, _ := conf.Check(path, p.FileSet, files, info)
+	return pkg, info, first
+}
+
+//testUnits type checks the test files of p, once, and returns a unit for
+//the package with its test files, and one for its external test package,
+//if there are any such files that parse. Type errors are tolerated,
+//as with TypeCheck.
+//
+//It is up to the caller to call TypeCheck before invoking this method.
+func (p *Package) testUnits() []*unit {
+	if p.testsChecked || p.Info == nil {
+		return p.tests
+	}
+	p.testsChecked = true
+
+	if files := p.parseTests(p.Build.TestGoFiles); len(files) > 0 {
+		u := &unit{p: p, files: files, test: true, decls: map[token.Pos]types.Object{}}
+		for id, obj := range p.Info.Defs {
+			if obj != nil {
+				u.decls[id.Pos()] = obj
+			}
+		}
+		u.types, u.info, _ = p.check(p.Build.ImportPath, append(p.astFiles(), files...))
+		p.tests = append(p.tests, u)
+	}
+	//the external test package imports p as any other package would.
+	if files := p.parseTests(p.Build.XTestGoFiles); len(files) > 0 {
+		u := &unit{p: p, files: files, test: true, external: true}
+		u.types, u.info, _ = p.check(p.Build.ImportPath+"_test", files)
+		p.tests = append(p.tests, u)
+	}
+	return p.tests
+}
+
+//parseTests parses the named test files of p into p.FileSet,
+//skipping those that do not parse.
+func (p *Package) parseTests(names []string) (files []*ast.File) {
+	for _, name := range names {
+		f, err := parser.ParseFile(p.FileSet, filepath.Join(p.Build.Dir, name), nil, parser.ParseComments)
+		if err == nil {
+			files = append(files, f)
+		}
+	}
+	return
+}
+
+//qualified matches an identifier qualified by an import path, such as
+//io.Writer or github.com/jimmyfrasche/goutil.Package.
+var qualified = regexp.MustCompile(`([A-Za-z_][\w\-]*(?:[./][\w\-]+)*)\.([A-Za-z_]\w*)`)
+
+//LookupType returns the type described by a Go type expression whose named
+//types are qualified by their complete import path instead of a package name.
+//For example,
+//	io.Writer
+//	*sync.Mutex
+//	map[string][]github.com/jimmyfrasche/goutil.Block
+//
+//Any packages referenced are imported with ctx and type checked.
+//If ctx is nil, the default context is used.
+func LookupType(ctx *build.Context, expr string) (types.Type, error) {
+	if ctx == nil {
+		ctx = defaultctx
+	}
+
+	//rewrite the expression into a file declaring an alias of the type.
+	paths := map[string]string{}
+	var imports string
+	src := qualified.ReplaceAllStringFunc(expr, func(s string) string {
+		m := qualified.FindStringSubmatch(s)
+		name, ok := paths[m[1]]
+		if !ok {
+			name = fmt.Sprintf("_q%d", len(paths))
+			paths[m[1]] = name
+			imports += fmt.Sprintf("import %s %q\n", name, m[1])
+		}
+		return name + "." + m[2]
+	})
+	src = "package _lookup\n" + imports + "type _T = " + src + "\n"
+
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, "lookup.go", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid type expression %s", expr)
+	}
+
+	conf := &types.Config{Importer: importer{ctx}}
+	pkg, err := conf.Check("_lookup", fs, []*ast.File{f}, nil)
+	if err != nil {
+		return nil, err
+	}
+	//the alias itself is a types.Alias when go/types materializes aliases.
+	return types.Unalias(pkg.Scope().Lookup("_T").Type()), nil
+}
+
+//Typed returns all Decls which declare an object satisfying the predicate f.
+//
+//The info must be the types.Info of the package the Decls came from,
+//as set by TypeCheck.
+//
+//If you haven't called SplitSpecs, a GenDecl will be returned
+//if any of its Spec's match.
+func (ds Decls) Typed(info *types.Info, f func(types.Object) bool) (out Decls) {
+	for _, d := range ds {
+		for _, id := range Idents(d) {
+			if obj := info.Defs[id]; obj != nil && f(obj) {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return
+}
+
+//Implements returns all type Decls declaring a non-interface type T such that
+//T or *T implements iface.
+//
+//See Typed for the requirements on info.
+func (ds Decls) Implements(info *types.Info, iface *types.Interface) Decls {
+	return ds.Typed(info, func(obj types.Object) bool {
+		tn, ok := obj.(*types.TypeName)
+		if !ok || types.IsInterface(tn.Type()) {
+			return false
+		}
+		t := tn.Type()
+		return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+	})
+}
+
+//AssignableTo returns all Decls of consts, vars, and functions whose type is
+//assignable to t. Methods are not included.
+//
+//For type Decls, the declared type itself is tested, so that
+//	AssignableTo(info, LookupType(nil, "error"))
+//returns every error type in the Decls, as well as every error variable.
+//
+//See Typed for the requirements on info.
+func (ds Decls) AssignableTo(info *types.Info, t types.Type) Decls {
+	return ds.Typed(info, func(obj types.Object) bool {
+		if f, ok := obj.(*types.Func); ok {
+			if f.Type().(*types.Signature).Recv() != nil {
+				return false
+			}
+		}
+		return types.AssignableTo(obj.Type(), t)
+	})
+}
+
+//SatisfiedBy returns all Decls of interfaces that t implements.
+//
+//See Typed for the requirements on info.
+func (ds Decls) SatisfiedBy(info *types.Info, t types.Type) Decls {
+	return ds.Typed(info, func(obj types.Object) bool {
+		tn, ok := obj.(*types.TypeName)
+		if !ok {
+			return false
+		}
+		iface, ok := tn.Type().Underlying().(*types.Interface)
+		return ok && types.Implements(t, iface)
+	})
+}
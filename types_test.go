@@ -0,0 +1,135 @@
+package goutil
+
+import (
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const typesSrc = `package a
+
+import (
+	"errors"
+	"io"
+)
+
+type R struct{}
+
+func (*R) Read([]byte) (int, error) { return 0, nil }
+
+func (*R) Close() error { return nil }
+
+type E string
+
+func (e E) Error() string { return string(e) }
+
+type I interface {
+	Error() string
+}
+
+type J interface {
+	Read([]byte) (int, error)
+	Close() error
+}
+
+var ErrX = errors.New("x")
+
+var N = 3
+
+func F() error { return nil }
+
+var _ io.Reader = (*R)(nil)
+`
+
+func TestTypeCheck(t *testing.T) {
+	p := importSrc(t, typesSrc)
+	if err := p.TypeCheck(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Types == nil || p.Info == nil {
+		t.Fatal("expected Types and Info to be set")
+	}
+	if p.Types.Scope().Lookup("R") == nil {
+		t.Error("expected R in the package scope")
+	}
+
+	bad := importSrc(t, "package a\n\nvar X int = \"x\"\n\nvar Y = 1\n")
+	if err := bad.TypeCheck(); err == nil {
+		t.Error("expected a type error")
+	}
+	if bad.Types == nil || bad.Types.Scope().Lookup("Y") == nil {
+		t.Error("expected Types to be set despite the error")
+	}
+}
+
+func TestLookupType(t *testing.T) {
+	table := []struct {
+		expr, out string
+	}{
+		{"int", "int"},
+		{"io.Writer", "io.Writer"},
+		{"*sync.Mutex", "*sync.Mutex"},
+		{"map[string][]go/token.Pos", "map[string][]go/token.Pos"},
+		{"func(io.Reader) error", "func(io.Reader) error"},
+		{"x.", ""},
+		{"nosuch/pkg.T", ""},
+	}
+	for _, c := range table {
+		typ, err := LookupType(nil, c.expr)
+		if c.out == "" {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", c.expr, typ)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.expr, err)
+			continue
+		}
+		if s := types.TypeString(typ, nil); s != c.out {
+			t.Errorf("%s: got %s", c.expr, s)
+		}
+	}
+}
+
+func TestTypedDecls(t *testing.T) {
+	p := importSrc(t, typesSrc)
+	if err := p.TypeCheck(); err != nil {
+		t.Fatal(err)
+	}
+	ds := p.Decls().SplitSpecs()
+	lookup := func(expr string) types.Type {
+		typ, err := LookupType(nil, expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return typ
+	}
+	iface := func(expr string) *types.Interface {
+		return lookup(expr).Underlying().(*types.Interface)
+	}
+	rt := p.Types.Scope().Lookup("R").Type()
+
+	table := []struct {
+		name string
+		ds   Decls
+		out  string
+	}{
+		{"Implements io.Reader", ds.Implements(p.Info, iface("io.Reader")), "R"},
+		{"Implements error", ds.Implements(p.Info, iface("error")), "E"},
+		{"AssignableTo error", ds.AssignableTo(p.Info, lookup("error")), "E I ErrX"},
+		{"AssignableTo int", ds.AssignableTo(p.Info, lookup("int")), "N"},
+		{"AssignableTo func() error", ds.AssignableTo(p.Info, lookup("func() error")), "F"},
+		{"SatisfiedBy *R", ds.SatisfiedBy(p.Info, types.NewPointer(rt)), "J"},
+		{"SatisfiedBy E", ds.SatisfiedBy(p.Info, p.Types.Scope().Lookup("E").Type()), "I"},
+	}
+	for _, c := range table {
+		var acc []string
+		for _, d := range c.ds {
+			acc = append(acc, Idents(d)[0].Name)
+		}
+		if out := strings.Join(acc, " "); out != c.out {
+			t.Errorf("%s: got %q expected %q", c.name, out, c.out)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+//Unused reports the unused declarations in a Go package,
+//or set of Go packages, with the standard build tags.
+//
+//An unexported declaration is unused if nothing in its package refers
+//to it. An exported declaration is unused if nothing in any of the
+//analyzed packages refers to it, so specify a tree of packages with
+//the special ... operator to find the exported API a tree does not use.
+//References from a declaration to itself, such as recursive calls,
+//do not count. The functions init and main are never reported.
+//
+//By default, a reference from a test file, or an external test file,
+//counts, so declarations used only in tests are not reported.
+//Use -notests to ignore test files.
+//
+//Methods may be used through interfaces, which unused cannot see,
+//so they are only reported with -methods.
+//
+//Unused exits with status 1 if anything is reported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jimmyfrasche/goutil"
+	"github.com/jimmyfrasche/goutil/gocli"
+)
+
+var (
+	notests = flag.Bool("notests", false, "do not count references from test files")
+	nogen   = flag.Bool("nogenerated", false, "do not report declarations in generated files")
+	methods = flag.Bool("methods", false, "also report methods")
+	exclude = flag.String("exclude", "", "do not report names matching `regexp`")
+	tags    = gocli.TagsFlag("")
+)
+
+func Usage() {
+	_, nm := filepath.Split(os.Args[0])
+	log.Printf("Usage: %s [flags] [packages]\n", nm)
+	flag.PrintDefaults()
+}
+
+func kind(d ast.Decl) string {
+	switch dt := d.(type) {
+	case *ast.FuncDecl:
+		if dt.Recv != nil {
+			return "method"
+		}
+		return "func"
+	case *ast.GenDecl:
+		return dt.Tok.String()
+	}
+	return ""
+}
+
+//Usage: %name %flags [packages]
+func main() {
+	log.SetFlags(0)
+	fatal := log.Fatalln
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	var m goutil.StringMatcher
+	if *exclude != "" {
+		re, err := regexp.Compile(*exclude)
+		if err != nil {
+			fatal(err)
+		}
+		m = re
+	}
+
+	pss, err := gocli.FirstError(gocli.Import(false, goutil.Context(*tags...), flag.Args()))
+	if err != nil {
+		fatal(err)
+	}
+	pkgs := gocli.Flatten(pss)
+
+	if err = pkgs.Parse(true); err != nil {
+		fatal(err)
+	}
+
+	ix, err := pkgs.Index()
+	if err != nil {
+		//type errors are common in dependencies and rarely fatal.
+		log.Println(err)
+	}
+
+	wd, _ := os.Getwd()
+	found := false
+	for _, p := range pkgs {
+		unused := ix.Unused(p, false, !*notests)
+		if *nogen {
+			unused = unused.NotGenerated(p)
+		}
+
+	decls:
+		for _, d := range unused {
+			if !*methods && kind(d) == "method" {
+				continue
+			}
+
+			var nms []string
+			for _, id := range goutil.Idents(d) {
+				if m != nil && m.MatchString(id.Name) {
+					continue decls
+				}
+				nms = append(nms, id.Name)
+			}
+
+			pos := p.Position(d).Name
+			if rel, err := filepath.Rel(wd, pos.Filename); err == nil && !strings.HasPrefix(rel, "..") {
+				pos.Filename = rel
+			}
+			fmt.Printf("%s: %s %s is unused\n", pos, kind(d), strings.Join(nms, ", "))
+			found = true
+		}
+	}
+
+	if found {
+		os.Exit(1)
+	}
+}